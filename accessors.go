@@ -0,0 +1,92 @@
+package main
+
+import "math/big"
+
+// AsObject returns v as an object. Objects parsed with PreserveOrder are
+// converted to an unordered map.
+func AsObject(v JSON) (map[string]JSON, bool) {
+	switch obj := v.(type) {
+	case map[string]JSON:
+		return obj, true
+	case *OrderedMap:
+		return obj.Map(), true
+	}
+	return nil, false
+}
+
+// AsArray returns v as an array.
+func AsArray(v JSON) ([]interface{}, bool) {
+	arr, ok := v.([]interface{})
+	return arr, ok
+}
+
+// AsString returns v as a string.
+func AsString(v JSON) (string, bool) {
+	s, ok := unwrapRaw(v).(string)
+	return s, ok
+}
+
+// AsNumber returns v as a float64, whichever numeric type the parser
+// produced for it.
+func AsNumber(v JSON) (float64, bool) {
+	switch n := unwrapRaw(v).(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, true
+	case *big.Float:
+		f, _ := n.Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// AsBool returns v as a bool.
+func AsBool(v JSON) (bool, bool) {
+	b, ok := unwrapRaw(v).(bool)
+	return b, ok
+}
+
+// ToStdlibShape converts a parsed tree into exactly the types encoding/json
+// produces when unmarshaling into an interface{}: map[string]interface{},
+// []interface{}, float64, string, bool and nil. Parser options such as
+// PreserveOrder, UseNumber and ExplicitNull do not change the result.
+func ToStdlibShape(v JSON) interface{} {
+	switch val := v.(type) {
+	case map[string]JSON:
+		obj := make(map[string]interface{}, len(val))
+		for key, elem := range val {
+			obj[key] = ToStdlibShape(elem)
+		}
+		return obj
+	case *OrderedMap:
+		obj := make(map[string]interface{}, val.Len())
+		for _, pair := range val.Pairs() {
+			obj[pair.Key] = ToStdlibShape(pair.Value)
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(val))
+		for i, elem := range val {
+			arr[i] = ToStdlibShape(elem)
+		}
+		return arr
+	case NullValue:
+		return nil
+	case RawValue:
+		return ToStdlibShape(val.Value)
+	}
+
+	if f, ok := AsNumber(v); ok {
+		return f
+	}
+	return v
+}
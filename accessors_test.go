@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAccessors(t *testing.T) {
+	v, err := Unmarshal([]byte(`{"name": "John", "age": 30, "score": 9.5, "verified": true, "friends": ["Jane"]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	obj, ok := AsObject(v)
+	if !ok {
+		t.Fatalf("AsObject(%v) failed", v)
+	}
+
+	if s, ok := AsString(obj["name"]); !ok || s != "John" {
+		t.Errorf("AsString = %q, %v, want John, true", s, ok)
+	}
+
+	if n, ok := AsNumber(obj["age"]); !ok || n != 30 {
+		t.Errorf("AsNumber(age) = %v, %v, want 30, true", n, ok)
+	}
+
+	if n, ok := AsNumber(obj["score"]); !ok || n != 9.5 {
+		t.Errorf("AsNumber(score) = %v, %v, want 9.5, true", n, ok)
+	}
+
+	if n, ok := AsNumber(Number("12")); !ok || n != 12 {
+		t.Errorf("AsNumber(Number) = %v, %v, want 12, true", n, ok)
+	}
+
+	if b, ok := AsBool(obj["verified"]); !ok || !b {
+		t.Errorf("AsBool = %v, %v, want true, true", b, ok)
+	}
+
+	if arr, ok := AsArray(obj["friends"]); !ok || !reflect.DeepEqual(arr, []interface{}{"Jane"}) {
+		t.Errorf("AsArray = %v, %v, want [Jane], true", arr, ok)
+	}
+}
+
+func TestAccessorsMismatch(t *testing.T) {
+	if _, ok := AsObject([]interface{}{}); ok {
+		t.Error("AsObject accepted an array")
+	}
+	if _, ok := AsArray("x"); ok {
+		t.Error("AsArray accepted a string")
+	}
+	if _, ok := AsString(1); ok {
+		t.Error("AsString accepted a number")
+	}
+	if _, ok := AsNumber("1"); ok {
+		t.Error("AsNumber accepted a string")
+	}
+	if _, ok := AsBool(nil); ok {
+		t.Error("AsBool accepted null")
+	}
+}
+
+func TestAsObjectOrdered(t *testing.T) {
+	m := NewOrderedMap()
+	m.Set("a", 1)
+
+	obj, ok := AsObject(m)
+	if !ok || !reflect.DeepEqual(obj, map[string]JSON{"a": 1}) {
+		t.Errorf("AsObject = %v, %v, want map[a:1], true", obj, ok)
+	}
+}
+
+func TestToStdlibShape(t *testing.T) {
+	input := `{"name": "John", "age": 30, "big": 10000000000000000000, "score": -9.5e1,
+		"ok": true, "none": null, "tags": ["a", 1, [2.5, {}]], "address": {"city": "NY"}}`
+
+	var want interface{}
+	if err := json.Unmarshal([]byte(input), &want); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	configure := []func(p *Parser){
+		func(p *Parser) {},
+		func(p *Parser) { p.PreserveOrder = true },
+		func(p *Parser) { p.UseNumber = true },
+		func(p *Parser) { p.ExplicitNull = true },
+	}
+
+	for i, setup := range configure {
+		p := NewParser(input)
+		setup(p)
+
+		v, err := p.Parse()
+		if err != nil {
+			t.Fatalf("config %d: unexpected error: %v", i, err)
+		}
+
+		if got := ToStdlibShape(v); !reflect.DeepEqual(got, want) {
+			t.Errorf("config %d: got %#v, want %#v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchDocument builds a representative document of roughly size bytes made
+// of user records like the one in main.
+func benchDocument(size int) []byte {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; sb.Len() < size; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"name": "User %d", "age": %d, "score": %d.5, "verified": %t, "friends": ["Jane", "James", "Jake"], "address": {"city": "New York", "state": "NY"}}`, i, 20+i%50, i, i%2 == 0)
+	}
+	sb.WriteString("]")
+	return []byte(sb.String())
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data := benchDocument(10 * 1024)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValid(b *testing.B) {
+	data := benchDocument(10 * 1024)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if !Valid(data) {
+			b.Fatal("invalid benchmark document")
+		}
+	}
+}
+
+func BenchmarkUnmarshalIntegers(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 100000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, "%d", (i*7919)%2000000-1000000)
+	}
+	sb.WriteString("]")
+	data := []byte(sb.String())
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// recordsDocument builds an array of n records sharing the same five keys.
+func recordsDocument(n int) []byte {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"id": %d, "name": "User %d", "email": "u%d@example.com", "active": %t, "score": %d.5}`, i, i, i, i%3 == 0, i%100)
+	}
+	sb.WriteString("]")
+	return []byte(sb.String())
+}
+
+func BenchmarkParseRecords(b *testing.B) {
+	data := recordsDocument(10000)
+
+	for _, intern := range []bool{false, true} {
+		b.Run(fmt.Sprintf("InternKeys=%t", intern), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				p := NewParserBytes(data)
+				p.InternKeys = intern
+				if _, err := p.Parse(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	v, err := Unmarshal(benchDocument(10 * 1024))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Marshal(v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("AppendMarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			if buf, err = AppendMarshal(buf[:0], v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkIterateObject(b *testing.B) {
+	data := []byte(wideObject(1000))
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		p := NewParserBytes(data)
+		err := p.IterateObject(func(key string, _ int) (bool, error) {
+			if key == "id" {
+				_, _, err := p.ParseValue()
+				return false, err
+			}
+			return false, nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import "bytes"
+
+// Compact returns data with the insignificant whitespace between tokens
+// removed. String contents and number literals are copied exactly as they
+// appear, so unlike a Parse and Marshal round trip the text of every value is
+// preserved. Malformed input produces the same error as Validate.
+func Compact(data []byte) ([]byte, error) {
+	if err := Validate(data); err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimPrefix(data, utf8BOM)
+	out := make([]byte, 0, len(data))
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case ' ', '\n', '\t', '\r':
+			continue
+		case '"':
+			end := stringEnd(data, i)
+			out = append(out, data[i:end]...)
+			i = end - 1
+			continue
+		}
+		out = append(out, c)
+	}
+
+	return out, nil
+}
+
+// Indent returns data reformatted the way MarshalIndent lays out a value:
+// each object member and array element on its own line, starting with prefix
+// followed by one copy of indent per nesting level. As with Compact, string
+// and number literals are copied unchanged.
+func Indent(data []byte, prefix, indent string) ([]byte, error) {
+	compact, err := Compact(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(compact)*2)
+	depth := 0
+	newline := func() {
+		out = append(out, '\n')
+		out = append(out, prefix...)
+		for i := 0; i < depth; i++ {
+			out = append(out, indent...)
+		}
+	}
+
+	for i := 0; i < len(compact); i++ {
+		c := compact[i]
+		switch c {
+		case '"':
+			end := stringEnd(compact, i)
+			out = append(out, compact[i:end]...)
+			i = end - 1
+		case BeginObject, BeginArray:
+			out = append(out, c)
+			// Keep empty containers on one line.
+			if i+1 < len(compact) && (compact[i+1] == EndObject || compact[i+1] == EndArray) {
+				out = append(out, compact[i+1])
+				i++
+				continue
+			}
+			depth++
+			newline()
+		case EndObject, EndArray:
+			depth--
+			newline()
+			out = append(out, c)
+		case ValueSeparator:
+			out = append(out, c)
+			newline()
+		case NameSeparator:
+			out = append(out, c, ' ')
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out, nil
+}
+
+// stringEnd returns the offset just past the closing quote of the string
+// starting at data[start]. data must already be known to be valid.
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			"pretty document",
+			"{\n  \"name\": \"John Doe\",\n  \"tags\": [\n    \"a b\",\n    \"c\\\"d\"\n  ],\n  \"n\": 1.50E+03\n}\n",
+			`{"name":"John Doe","tags":["a b","c\"d"],"n":1.50E+03}`,
+		},
+		{"whitespace in strings", `[ " spaced  out ", "tab\t"  ]`, `[" spaced  out ","tab\t"]`},
+		{"escaped backslash before quote", `{ "a\\" : 1 }`, `{"a\\":1}`},
+		{"scalar", "  -0.0e0 \r\n", `-0.0e0`},
+		{"byte order mark", "\xEF\xBB\xBF [ 1 ]", `[1]`},
+		{"already compact", `{"a":[1,2,{}]}`, `{"a":[1,2,{}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compact([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompactInvalid(t *testing.T) {
+	for _, input := range []string{``, `{"a": 1,}`, `[1 2]`, `"open`, `{} x`} {
+		if _, err := Compact([]byte(input)); err == nil {
+			t.Errorf("Compact(%q): expected error", input)
+		}
+	}
+
+	_, err := Compact([]byte("{\n  \"a\": tru\n}"))
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if perr.Line != 2 {
+		t.Errorf("got line %d, want 2", perr.Line)
+	}
+}
+
+func TestIndent(t *testing.T) {
+	input := `{"name":"John Doe","price":1.0,"id":12345678901234567890,"tags":["a, b",{}],"empty":[],"nested":{"ok":true}}`
+	want := `{
+> 	"name": "John Doe",
+> 	"price": 1.0,
+> 	"id": 12345678901234567890,
+> 	"tags": [
+> 		"a, b",
+> 		{}
+> 	],
+> 	"empty": [],
+> 	"nested": {
+> 		"ok": true
+> 	}
+> }`
+
+	got, err := Indent([]byte(input), "> ", "\t")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Without literals that Marshal would rewrite, Indent matches MarshalIndent.
+	doc := `{"a":[1,"x",{"b":null}],"c":{}}`
+	v, err := NewParser(doc).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromValue, err := MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromText, err := Indent([]byte(doc), "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(fromText) != string(fromValue) {
+		t.Errorf("Indent = %s, MarshalIndent = %s", fromText, fromValue)
+	}
+
+	if _, err := Indent([]byte(`{"a":}`), "", "  "); err == nil {
+		t.Error("expected error for malformed input")
+	}
+}
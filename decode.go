@@ -0,0 +1,477 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type DecodeError struct {
+	msg   string
+	field string
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Decode error at field %s: %s", e.field, e.msg)
+}
+
+// DecodeOptions controls how UnmarshalInto maps JSON onto Go values.
+type DecodeOptions struct {
+	// DisallowUnknownFields makes an object key with no matching struct
+	// field an error instead of being ignored.
+	DisallowUnknownFields bool
+
+	// CaseSensitiveKeys requires an object key to match the json tag or
+	// field name exactly instead of case-insensitively.
+	CaseSensitiveKeys bool
+}
+
+// UnmarshalInto parses data and stores the result in the value pointed to
+// by dst.
+func UnmarshalInto(data []byte, dst interface{}) error {
+	return DecodeOptions{}.UnmarshalInto(data, dst)
+}
+
+// UnmarshalInto parses data and stores the result in the value pointed to by
+// dst using the options in o.
+func (o DecodeOptions) UnmarshalInto(data []byte, dst interface{}) error {
+	if !o.DisallowUnknownFields && !needsSource(reflect.TypeOf(dst), map[reflect.Type]bool{}) {
+		v, err := Unmarshal(data)
+		if err != nil {
+			return err
+		}
+		return (&decodeState{opts: o}).populateRoot(v, nil, dst)
+	}
+
+	// Keep the source spans so an unknown key can be reported with the
+	// position of its value, and a RawMessage or JSONUnmarshaler can get
+	// its source text.
+	root, err := NewParserBytes(data).ParseWithSpans()
+	if err != nil {
+		return err
+	}
+
+	var v JSON
+	if root != nil {
+		v = root.Value
+	}
+
+	d := &decodeState{opts: o, data: data}
+	return d.populateRoot(v, root, dst)
+}
+
+// Decode parses data and converts it into a value of type T.
+func Decode[T any](data []byte) (T, error) {
+	var v T
+	err := UnmarshalInto(data, &v)
+	return v, err
+}
+
+// Populate stores a parsed JSON value in the value pointed to by dst.
+//
+// Object keys are matched against the json tag of each struct field, falling
+// back to a case-insensitive match on the field name. The fields of embedded
+// structs are matched as if they belonged to the outer struct. Keys without
+// a matching field are ignored.
+func Populate(v JSON, dst interface{}) error {
+	return (&decodeState{}).populateRoot(v, nil, dst)
+}
+
+// decodeState carries the options of one decode through populate. Each
+// populate call also gets the span node of its value, or nil when spans are
+// not kept.
+type decodeState struct {
+	opts DecodeOptions
+
+	// data is the source the span nodes refer to.
+	data []byte
+}
+
+func (d *decodeState) populateRoot(v JSON, node *SpanNode, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &DecodeError{msg: fmt.Sprintf("destination must be a non-nil pointer, got %T", dst), field: "$"}
+	}
+
+	root := rv.Elem().Type().Name()
+	if root == "" {
+		root = "$"
+	}
+
+	return d.populate(v, node, rv.Elem(), root)
+}
+
+func (d *decodeState) populate(v JSON, node *SpanNode, rv reflect.Value, path string) error {
+	if rv.Type() == rawMessageType {
+		return d.populateRaw(v, node, rv, path)
+	}
+
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() && rv.CanInterface() {
+		if u, ok := rv.Addr().Interface().(JSONUnmarshaler); ok {
+			return d.populateUnmarshaler(v, node, u, path)
+		}
+	}
+
+	v = unwrapRaw(v)
+	if v == nil || v == Null {
+		switch rv.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.populate(v, node, rv.Elem(), path)
+
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return mismatch(v, rv, path)
+		}
+		rv.Set(reflect.ValueOf(v))
+
+	case reflect.Struct:
+		obj, ok := AsObject(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+		return d.populateStruct(obj, node, rv, path)
+
+	case reflect.Map:
+		obj, ok := AsObject(v)
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			return mismatch(v, rv, path)
+		}
+
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(obj)))
+		}
+
+		for key, val := range obj {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := d.populate(val, member(node, key), elem, path+"."+key); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), elem)
+		}
+
+	case reflect.Slice:
+		arr, ok := AsArray(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+
+		s := reflect.MakeSlice(rv.Type(), len(arr), len(arr))
+		for i, val := range arr {
+			if err := d.populate(val, element(node, i), s.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+
+	case reflect.Array:
+		arr, ok := AsArray(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			if i >= len(arr) {
+				rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+				continue
+			}
+			if err := d.populate(arr[i], element(node, i), rv.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.String:
+		s, ok := AsString(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+		rv.SetString(s)
+
+	case reflect.Bool:
+		b, ok := AsBool(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+		rv.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := toInt64(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+		if rv.OverflowInt(n) {
+			return &DecodeError{msg: fmt.Sprintf("number %v overflows %s", v, rv.Type()), field: path}
+		}
+		rv.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := toUint64(v)
+		if node != nil {
+			// The parsed value of an integer above MaxInt64 is a rounded
+			// float64, so prefer the exact source text.
+			if u, err := strconv.ParseUint(string(d.data[node.Span.Start:node.Span.End]), 10, 64); err == nil {
+				n, ok = u, true
+			}
+		}
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+		if rv.OverflowUint(n) {
+			return &DecodeError{msg: fmt.Sprintf("number %v overflows %s", v, rv.Type()), field: path}
+		}
+		rv.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := AsNumber(v)
+		if !ok {
+			return mismatch(v, rv, path)
+		}
+		if rv.OverflowFloat(f) {
+			return &DecodeError{msg: fmt.Sprintf("number %v overflows %s", v, rv.Type()), field: path}
+		}
+		rv.SetFloat(f)
+
+	default:
+		return &DecodeError{msg: fmt.Sprintf("unsupported destination type %s", rv.Type()), field: path}
+	}
+
+	return nil
+}
+
+func (d *decodeState) populateStruct(obj map[string]JSON, node *SpanNode, rv reflect.Value, path string) error {
+	t := rv.Type()
+
+	var matched map[string]bool
+	if d.opts.DisallowUnknownFields {
+		matched = make(map[string]bool, len(obj))
+	}
+
+	for _, sf := range structFields(t) {
+		f := sf.field
+		key, ok := lookupKey(obj, sf.name, d.opts.CaseSensitiveKeys)
+		if !ok {
+			continue
+		}
+
+		if matched != nil {
+			matched[key] = true
+		}
+
+		field, err := settableField(rv, sf.index)
+		if err != nil {
+			return &DecodeError{msg: err.Error(), field: path + "." + f.Name}
+		}
+		if typeKey, ok := tagOption(f, "discriminator"); ok && field.Kind() == reflect.Interface {
+			if err := d.populateDiscriminated(obj, typeKey, obj[key], member(node, key), field, path+"."+f.Name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := d.populate(obj[key], member(node, key), field, path+"."+f.Name); err != nil {
+			return err
+		}
+	}
+
+	if matched == nil {
+		return nil
+	}
+
+	for _, key := range sortedKeys(obj) {
+		if matched[key] {
+			continue
+		}
+
+		msg := fmt.Sprintf("unknown field %q", key)
+		if n := member(node, key); n != nil {
+			msg = fmt.Sprintf("unknown field %q at position %d", key, n.Span.Start)
+		}
+		return &DecodeError{msg: msg, field: path}
+	}
+
+	return nil
+}
+
+// populateRaw stores the source text of v in a RawMessage.
+func (d *decodeState) populateRaw(v JSON, node *SpanNode, rv reflect.Value, path string) error {
+	raw, err := d.source(v, node, path)
+	if err != nil {
+		return err
+	}
+	rv.SetBytes(raw)
+	return nil
+}
+
+// populateUnmarshaler hands the source text of v to a JSONUnmarshaler.
+func (d *decodeState) populateUnmarshaler(v JSON, node *SpanNode, u JSONUnmarshaler, path string) error {
+	raw, err := d.source(v, node, path)
+	if err != nil {
+		return err
+	}
+	if err := u.UnmarshalJSON(raw); err != nil {
+		return &DecodeError{msg: err.Error(), field: path}
+	}
+	return nil
+}
+
+// source returns a copy of the source text of v, falling back to re-encoding
+// v when its span is not known.
+func (d *decodeState) source(v JSON, node *SpanNode, path string) ([]byte, error) {
+	if node != nil {
+		raw := make([]byte, node.Span.End-node.Span.Start)
+		copy(raw, d.data[node.Span.Start:node.Span.End])
+		return raw, nil
+	}
+
+	raw, err := Marshal(v)
+	if err != nil {
+		return nil, &DecodeError{msg: err.Error(), field: path}
+	}
+	return raw, nil
+}
+
+// settableField returns the field of rv at index, allocating any nil
+// embedded pointers on the way to it.
+func settableField(rv reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct type %s", rv.Type().Elem())
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, nil
+}
+
+// member returns the span of the value under key in the object at node.
+func member(node *SpanNode, key string) *SpanNode {
+	if node == nil {
+		return nil
+	}
+	return node.Members[key]
+}
+
+// element returns the span of the i'th value in the array at node.
+func element(node *SpanNode, i int) *SpanNode {
+	if node == nil || i >= len(node.Elements) {
+		return nil
+	}
+	return node.Elements[i]
+}
+
+// fieldName returns the JSON key for a struct field, taken from its json tag
+// or the field name itself.
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// lookupKey finds the key in obj matching name, preferring an exact match
+// over a case-insensitive one. With caseSensitive only an exact match counts.
+func lookupKey(obj map[string]JSON, name string, caseSensitive bool) (string, bool) {
+	if _, ok := obj[name]; ok {
+		return name, true
+	}
+
+	if caseSensitive {
+		return "", false
+	}
+
+	for key := range obj {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func toInt64(v JSON) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case Number:
+		i, err := n.Int64()
+		return i, err == nil
+	case *big.Int:
+		return n.Int64(), n.IsInt64()
+	case *big.Float:
+		i, acc := n.Int64()
+		return i, acc == big.Exact
+	}
+	return 0, false
+}
+
+// toUint64 returns v as a uint64 if it is a non-negative integer that fits.
+func toUint64(v JSON) (uint64, bool) {
+	switch n := v.(type) {
+	case int:
+		return uint64(n), n >= 0
+	case int64:
+		return uint64(n), n >= 0
+	case float64:
+		if n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 {
+			return 0, false
+		}
+		return uint64(n), true
+	case Number:
+		u, err := strconv.ParseUint(string(n), 10, 64)
+		return u, err == nil
+	case *big.Int:
+		return n.Uint64(), n.IsUint64()
+	case *big.Float:
+		u, acc := n.Uint64()
+		return u, acc == big.Exact && n.Sign() >= 0
+	}
+	return 0, false
+}
+
+func mismatch(v JSON, rv reflect.Value, path string) error {
+	return &DecodeError{msg: fmt.Sprintf("cannot decode %s into %s", kindOf(v), rv.Type()), field: path}
+}
+
+// kindOf names the JSON type of a parsed value for error messages.
+func kindOf(v JSON) string {
+	switch unwrapRaw(v).(type) {
+	case nil, NullValue:
+		return "null"
+	case map[string]JSON, *OrderedMap:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64, float64, Number, *big.Int, *big.Float:
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testAddress struct {
+	City  string `json:"city"`
+	State string `json:"state"`
+}
+
+type testUser struct {
+	Name     string      `json:"name"`
+	Age      int         `json:"age"`
+	Verified bool        `json:"verified"`
+	Friends  []string    `json:"friends"`
+	Address  testAddress `json:"address"`
+	Manager  *testUser   `json:"manager"`
+	Nickname string      `json:"-"`
+	Score    float64
+}
+
+func TestUnmarshalInto(t *testing.T) {
+	input := `{
+		"name": "John Doe",
+		"age": 30,
+		"verified": true,
+		"friends": ["Jane", "James"],
+		"address": {"city": "New York", "state": "NY"},
+		"manager": {"name": "Jane", "age": 41},
+		"Nickname": "JD",
+		"score": 9.5,
+		"unknown": [1, 2, 3]
+	}`
+
+	var got testUser
+	if err := UnmarshalInto([]byte(input), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := testUser{
+		Name:     "John Doe",
+		Age:      30,
+		Verified: true,
+		Friends:  []string{"Jane", "James"},
+		Address:  testAddress{City: "New York", State: "NY"},
+		Manager:  &testUser{Name: "Jane", Age: 41},
+		Score:    9.5,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalIntoNull(t *testing.T) {
+	got := testUser{Manager: &testUser{Name: "old"}, Friends: []string{"x"}}
+	if err := UnmarshalInto([]byte(`{"manager": null, "friends": null}`), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Manager != nil || got.Friends != nil {
+		t.Errorf("expected null to clear pointer and slice, got %+v", got)
+	}
+}
+
+func TestUnmarshalIntoLargeUint(t *testing.T) {
+	type counters struct {
+		Max  uint64   `json:"max"`
+		List []uint64 `json:"list"`
+		Neg  uint64   `json:"neg"`
+	}
+
+	in := counters{Max: 18446744073709551615, List: []uint64{9223372036854775808, 1}}
+	data, err := MarshalStruct(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out counters
+	if err := UnmarshalInto(data, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip gave %+v, want %+v", out, in)
+	}
+
+	if err := UnmarshalInto([]byte(`{"neg": -1}`), &out); err == nil {
+		t.Error("expected error for a negative uint64")
+	}
+	if err := UnmarshalInto([]byte(`{"max": 18446744073709551616}`), &out); err == nil {
+		t.Error("expected error for a uint64 overflow")
+	}
+
+	var n uint64
+	if err := Populate(Number("18446744073709551615"), &n); err != nil || n != 18446744073709551615 {
+		t.Errorf("Populate: got %d, %v", n, err)
+	}
+}
+
+func TestUnmarshalIntoTypeMismatch(t *testing.T) {
+	tests := []struct {
+		input string
+		field string
+	}{
+		{`{"age": "thirty"}`, "testUser.Age"},
+		{`{"address": {"city": 5}}`, "testUser.Address.City"},
+		{`{"friends": ["a", 1]}`, "testUser.Friends[1]"},
+		{`{"age": 1.5}`, "testUser.Age"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var u testUser
+			err := UnmarshalInto([]byte(tt.input), &u)
+
+			derr, ok := err.(*DecodeError)
+			if !ok {
+				t.Fatalf("expected *DecodeError, got %v", err)
+			}
+
+			if derr.field != tt.field || !strings.Contains(derr.Error(), tt.field) {
+				t.Errorf("got error %q, want field %s", derr.Error(), tt.field)
+			}
+		})
+	}
+}
+
+func TestPopulateRequiresPointer(t *testing.T) {
+	var u testUser
+	if err := Populate(map[string]JSON{}, u); err == nil {
+		t.Error("expected error for non-pointer destination")
+	}
+}
+
+func TestPopulateExplicitNull(t *testing.T) {
+	u := testUser{Manager: &testUser{}}
+	if err := Populate(map[string]JSON{"manager": Null, "name": "x"}, &u); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if u.Manager != nil || u.Name != "x" {
+		t.Errorf("got %+v, want nil manager and name x", u)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	ints, err := Decode[[]int]([]byte(`[1, 2, 3]`))
+	if err != nil || !reflect.DeepEqual(ints, []int{1, 2, 3}) {
+		t.Errorf("Decode[[]int] = %v, %v, want [1 2 3]", ints, err)
+	}
+
+	u, err := Decode[testUser]([]byte(`{"name": "Jo", "address": {"city": "NY"}}`))
+	if err != nil || u.Name != "Jo" || u.Address.City != "NY" {
+		t.Errorf("Decode[testUser] = %+v, %v, want name Jo in NY", u, err)
+	}
+
+	m, err := Decode[map[string]string]([]byte(`{"a": "x", "b": "y"}`))
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "x", "b": "y"}) {
+		t.Errorf("Decode[map[string]string] = %v, %v, want map[a:x b:y]", m, err)
+	}
+
+	f, err := Decode[float64]([]byte(`2.5`))
+	if err != nil || f != 2.5 {
+		t.Errorf("Decode[float64] = %v, %v, want 2.5", f, err)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	if _, err := Decode[[]int]([]byte(`[1, "two"]`)); err == nil {
+		t.Error("expected error decoding a string into an int")
+	}
+
+	if _, err := Decode[[]int]([]byte(`[1,`)); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	input := []byte(`{"name": "Jo", "address": {"city": "NY", "zip": "10001"}}`)
+
+	var got testUser
+	if err := UnmarshalInto(input, &got); err != nil {
+		t.Fatalf("unexpected error by default: %v", err)
+	}
+
+	opts := DecodeOptions{DisallowUnknownFields: true}
+	err := opts.UnmarshalInto(input, &got)
+
+	derr, ok := err.(*DecodeError)
+	if !ok {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+
+	if derr.field != "testUser.Address" {
+		t.Errorf("got field %q, want %q", derr.field, "testUser.Address")
+	}
+
+	if want := `unknown field "zip" at position 48`; derr.msg != want {
+		t.Errorf("got %q, want %q", derr.msg, want)
+	}
+
+	if err := opts.UnmarshalInto([]byte(`{"NAME": "Jo", "friends": ["a"]}`), &got); err != nil {
+		t.Errorf("unexpected error for known fields: %v", err)
+	}
+}
+
+func TestCaseSensitiveKeys(t *testing.T) {
+	input := []byte(`{"Name": "Jo", "age": 30}`)
+
+	var got testUser
+	if err := UnmarshalInto(input, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Jo" {
+		t.Errorf("case-insensitive: got Name %q, want %q", got.Name, "Jo")
+	}
+
+	got = testUser{}
+	opts := DecodeOptions{CaseSensitiveKeys: true}
+	if err := opts.UnmarshalInto(input, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "" {
+		t.Errorf("case-sensitive: got Name %q, want it left empty", got.Name)
+	}
+	if got.Age != 30 {
+		t.Errorf("case-sensitive: got Age %d, want 30", got.Age)
+	}
+
+	got = testUser{}
+	if err := opts.UnmarshalInto([]byte(`{"name": "Jo"}`), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Jo" {
+		t.Errorf("case-sensitive exact key: got Name %q, want %q", got.Name, "Jo")
+	}
+
+	opts.DisallowUnknownFields = true
+	err := opts.UnmarshalInto(input, &got)
+	if derr, ok := err.(*DecodeError); !ok || !strings.Contains(derr.msg, `unknown field "Name"`) {
+		t.Errorf("got %v, want unknown field error for \"Name\"", err)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Delim is a JSON array or object delimiter, one of [ ] { or }.
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+type tokenState int
+
+const (
+	tokenTopValue tokenState = iota
+	tokenArrayStart
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectStart
+	tokenObjectKey
+	tokenObjectColon
+	tokenObjectValue
+	tokenObjectComma
+)
+
+// Decoder reads JSON values from an input stream one token at a time, so a
+// large array can be walked without holding the whole document in memory.
+type Decoder struct {
+	r     *bufio.Reader
+	pos   int
+	state tokenState
+	stack []tokenState
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Token returns the next JSON token in the input stream: a Delim for the
+// start or end of an array or object, or a string, number, bool or nil for
+// scalars. Commas and colons are checked and consumed but not returned. At
+// the end of the input Token returns io.EOF.
+func (d *Decoder) Token() (interface{}, error) {
+	for {
+		c, err := d.peek()
+		if err == io.EOF {
+			if d.state == tokenTopValue && len(d.stack) == 0 {
+				return nil, io.EOF
+			}
+			return nil, &ParseError{msg: "unexpected end of input", pos: d.pos, Kind: KindUnexpectedEOF}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch c {
+		case BeginArray, BeginObject:
+			if !d.acceptsValue() {
+				return nil, d.unexpected(c)
+			}
+			d.advance()
+			d.stack = append(d.stack, d.state)
+			if c == BeginArray {
+				d.state = tokenArrayStart
+			} else {
+				d.state = tokenObjectStart
+			}
+			return Delim(c), nil
+
+		case EndArray:
+			if d.state != tokenArrayStart && d.state != tokenArrayComma {
+				return nil, d.unexpected(c)
+			}
+			d.advance()
+			d.pop()
+			return Delim(c), nil
+
+		case EndObject:
+			if d.state != tokenObjectStart && d.state != tokenObjectComma {
+				return nil, d.unexpected(c)
+			}
+			d.advance()
+			d.pop()
+			return Delim(c), nil
+
+		case NameSeparator:
+			if d.state != tokenObjectColon {
+				return nil, d.unexpected(c)
+			}
+			d.advance()
+			d.state = tokenObjectValue
+
+		case ValueSeparator:
+			switch d.state {
+			case tokenArrayComma:
+				d.state = tokenArrayValue
+			case tokenObjectComma:
+				d.state = tokenObjectKey
+			default:
+				return nil, d.unexpected(c)
+			}
+			d.advance()
+
+		case '"':
+			if d.state == tokenObjectStart || d.state == tokenObjectKey {
+				key, err := d.readScalar()
+				if err != nil {
+					return nil, err
+				}
+				d.state = tokenObjectColon
+				return key, nil
+			}
+			fallthrough
+
+		default:
+			if !d.acceptsValue() {
+				return nil, d.unexpected(c)
+			}
+			value, err := d.readScalar()
+			if err != nil {
+				return nil, err
+			}
+			d.valueDone()
+			return value, nil
+		}
+	}
+}
+
+// Decode reads the next complete JSON value from the input stream. It
+// returns io.EOF once the input is exhausted, so it can be called in a loop
+// to read a stream of concatenated or newline-separated documents.
+func (d *Decoder) Decode() (JSON, error) {
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case Delim(BeginArray):
+		arr := make([]interface{}, 0)
+		for d.More() {
+			value, err := d.Decode()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		if _, err := d.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+
+	case Delim(BeginObject):
+		obj := make(map[string]JSON)
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.Decode()
+			if err != nil {
+				return nil, err
+			}
+			obj[key.(string)] = value
+		}
+		if _, err := d.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+
+	case Delim(EndArray), Delim(EndObject):
+		return nil, d.unexpected(byte(tok.(Delim)))
+	}
+
+	return tok, nil
+}
+
+// More reports whether there is another element in the current array or
+// object being read.
+func (d *Decoder) More() bool {
+	c, err := d.peek()
+	return err == nil && c != EndArray && c != EndObject
+}
+
+func (d *Decoder) acceptsValue() bool {
+	switch d.state {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+// valueDone moves the decoder past a complete value in the current container.
+func (d *Decoder) valueDone() {
+	switch d.state {
+	case tokenArrayStart, tokenArrayValue:
+		d.state = tokenArrayComma
+	case tokenObjectValue:
+		d.state = tokenObjectComma
+	}
+}
+
+func (d *Decoder) pop() {
+	d.state = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+	d.valueDone()
+}
+
+func (d *Decoder) unexpected(c byte) error {
+	return &ParseError{msg: fmt.Sprintf("unexpected character %s", quoteChar([]byte{c}, 0)), pos: d.pos}
+}
+
+// peek skips insignificant whitespace and returns the next byte without
+// consuming it.
+func (d *Decoder) peek() (byte, error) {
+	for {
+		b, err := d.r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+
+		switch b[0] {
+		case ' ', '\n', '\t', '\r':
+			d.advance()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func (d *Decoder) advance() {
+	d.r.ReadByte()
+	d.pos++
+}
+
+// readScalar reads the raw bytes of the string, number or literal at the
+// current position and decodes them with Parser.
+func (d *Decoder) readScalar() (JSON, error) {
+	start := d.pos
+	var raw []byte
+
+	c, _ := d.r.ReadByte()
+	raw = append(raw, c)
+	d.pos++
+
+	for {
+		b, err := d.r.Peek(1)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if c == '"' {
+			d.advance()
+			raw = append(raw, b[0])
+			if b[0] == '\\' {
+				next, err := d.r.ReadByte()
+				if err != nil {
+					break
+				}
+				d.pos++
+				raw = append(raw, next)
+			} else if b[0] == '"' {
+				break
+			}
+			continue
+		}
+
+		if isDelimiter(b[0]) {
+			break
+		}
+		d.advance()
+		raw = append(raw, b[0])
+	}
+
+	p := NewParserBytes(raw)
+	value, err := p.parseValue()
+	if err == nil && p.pos < len(raw) {
+		err = &ParseError{msg: fmt.Sprintf("unexpected character %s", quoteChar(raw, p.pos)), pos: p.pos}
+	}
+	if perr, ok := err.(*ParseError); ok {
+		perr.pos += start
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// isDelimiter reports whether c ends a number or literal.
+func isDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\n', '\t', '\r', BeginArray, BeginObject, EndArray, EndObject, NameSeparator, ValueSeparator, '"':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestDecoderToken(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[1, "two", {"k": true, "n": null}, [2.5]]`))
+
+	want := []interface{}{
+		Delim('['), 1, "two", Delim('{'), "k", true, "n", nil, Delim('}'),
+		Delim('['), 2.5, Delim(']'), Delim(']'),
+	}
+
+	var got []interface{}
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, token)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestDecoderMore(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[{"id": 1}, {"id": 2}, {"id": 3}]`))
+
+	if token, err := d.Token(); err != nil || token != Delim('[') {
+		t.Fatalf("got %v, %v, want [", token, err)
+	}
+
+	var ids []interface{}
+	for d.More() {
+		for _, want := range []interface{}{Delim('{'), "id"} {
+			if token, err := d.Token(); err != nil || token != want {
+				t.Fatalf("got %v, %v, want %v", token, err, want)
+			}
+		}
+
+		id, err := d.Token()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, id)
+
+		if token, err := d.Token(); err != nil || token != Delim('}') {
+			t.Fatalf("got %v, %v, want }", token, err)
+		}
+	}
+
+	if token, err := d.Token(); err != nil || token != Delim(']') {
+		t.Fatalf("got %v, %v, want ]", token, err)
+	}
+
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+
+	if _, err := d.Token(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestDecoderTokenErrors(t *testing.T) {
+	tests := []string{
+		`[1 2]`,
+		`{"a" 1}`,
+		`{1: 2}`,
+		`[1,]`,
+		`[1}`,
+		`[1, 2`,
+		`[tru]`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(input))
+			for {
+				_, err := d.Token()
+				if err == io.EOF {
+					t.Fatal("expected error, got io.EOF")
+				}
+				if err != nil {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestDecoderDecode(t *testing.T) {
+	input := "{\"id\": 1, \"tags\": [\"a\"]}\n{\"id\": 2}{\"id\": 3, \"nested\": {\"ok\": true}}\n\n"
+	d := NewDecoder(iotest.OneByteReader(strings.NewReader(input)))
+
+	want := []JSON{
+		map[string]JSON{"id": 1, "tags": []interface{}{"a"}},
+		map[string]JSON{"id": 2},
+		map[string]JSON{"id": 3, "nested": map[string]JSON{"ok": true}},
+	}
+
+	for i, w := range want {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatalf("value %d: unexpected error: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("value %d: got %#v, want %#v", i, got, w)
+		}
+	}
+
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestDecoderDecodeTruncated(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[1, {"a": 2}`))
+
+	_, err := d.Decode()
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("expected *ParseError, got %v", err)
+	}
+}
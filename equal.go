@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// Equal reports whether a and b are the same JSON value. Objects are
+// compared without regard to key order, and numbers by value, so 1 and 1.0
+// are equal. Two integers are compared exactly, even beyond the precision
+// of float64.
+func Equal(a, b JSON) bool {
+	return Diff(a, b) == ""
+}
+
+// Diff describes the first difference Equal finds between a and b, or
+// returns "" if they are equal.
+func Diff(a, b JSON) string {
+	return diff(a, b, "$")
+}
+
+func diff(a, b JSON, path string) string {
+	a, b = unwrapRaw(a), unwrapRaw(b)
+	ka, kb := kindOf(a), kindOf(b)
+	if ka != kb {
+		return fmt.Sprintf("%s: %s != %s", path, ka, kb)
+	}
+
+	switch ka {
+	case "null":
+		return ""
+
+	case "number":
+		ia, aInt := asBigInt(a)
+		ib, bInt := asBigInt(b)
+		if aInt && bInt {
+			if ia.Cmp(ib) != 0 {
+				return fmt.Sprintf("%s: %v != %v", path, a, b)
+			}
+			break
+		}
+
+		fa, _ := AsNumber(a)
+		fb, _ := AsNumber(b)
+		if fa != fb {
+			return fmt.Sprintf("%s: %v != %v", path, a, b)
+		}
+
+	case "object":
+		oa, _ := AsObject(a)
+		ob, _ := AsObject(b)
+
+		for _, key := range sortedKeys(oa) {
+			vb, ok := ob[key]
+			if !ok {
+				return fmt.Sprintf("%s: key %q only in first value", path, key)
+			}
+			if d := diff(oa[key], vb, path+"."+key); d != "" {
+				return d
+			}
+		}
+
+		for _, key := range sortedKeys(ob) {
+			if _, ok := oa[key]; !ok {
+				return fmt.Sprintf("%s: key %q only in second value", path, key)
+			}
+		}
+
+	case "array":
+		aa, _ := AsArray(a)
+		ab, _ := AsArray(b)
+
+		for i := 0; i < len(aa) && i < len(ab); i++ {
+			if d := diff(aa[i], ab[i], fmt.Sprintf("%s[%d]", path, i)); d != "" {
+				return d
+			}
+		}
+
+		if len(aa) != len(ab) {
+			return fmt.Sprintf("%s: length %d != %d", path, len(aa), len(ab))
+		}
+
+	default:
+		if !reflect.DeepEqual(a, b) {
+			return fmt.Sprintf("%s: %#v != %#v", path, a, b)
+		}
+	}
+
+	return ""
+}
+
+// asBigInt returns v as a *big.Int if it is an integer: an int, int64,
+// *big.Int or a Number written without fraction or exponent.
+func asBigInt(v JSON) (*big.Int, bool) {
+	switch n := v.(type) {
+	case int:
+		return big.NewInt(int64(n)), true
+	case int64:
+		return big.NewInt(n), true
+	case *big.Int:
+		return n, n != nil
+	case Number:
+		return new(big.Int).SetString(string(n), 10)
+	}
+	return nil, false
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	ordered := NewOrderedMap()
+	ordered.Set("b", []interface{}{1, 2.5})
+	ordered.Set("a", "x")
+
+	tests := []struct {
+		name string
+		a, b JSON
+		diff string
+	}{
+		{"reordered objects", map[string]JSON{"a": "x", "b": []interface{}{1, 2.5}}, ordered, ""},
+		{"int and float", 1, 1.0, ""},
+		{"number literal", Number("2e3"), 2000, ""},
+		{"nil and Null", nil, Null, ""},
+		{"large ints", 9007199254740993, 9007199254740992, "$: 9007199254740993 != 9007199254740992"},
+		{"large number literals", Number("10000000000000000001"), Number("10000000000000000000"), "$: 10000000000000000001 != 10000000000000000000"},
+		{"big.Int and number literal", mustBigInt("10000000000000000001"), Number("10000000000000000001"), ""},
+		{"large int and float", Number("10000000000000000000"), 1e19, ""},
+		{"different numbers", []interface{}{1, 2}, []interface{}{1, 3}, "$[1]: 2 != 3"},
+		{"different order", []interface{}{1, 2}, []interface{}{2, 1}, "$[0]: 1 != 2"},
+		{"different length", []interface{}{1}, []interface{}{1, 2}, "$: length 1 != 2"},
+		{"different kinds", map[string]JSON{"a": "1"}, map[string]JSON{"a": 1}, "$.a: string != number"},
+		{"missing key", map[string]JSON{"a": 1}, map[string]JSON{"a": 1, "b": 2}, `$: key "b" only in second value`},
+		{"extra key", map[string]JSON{"a": 1, "c": 3}, map[string]JSON{"a": 1}, `$: key "c" only in first value`},
+		{"different strings", "x", "y", `$: "x" != "y"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.a, tt.b); got != tt.diff {
+				t.Errorf("Diff = %q, want %q", got, tt.diff)
+			}
+
+			if got := Equal(tt.a, tt.b); got != (tt.diff == "") {
+				t.Errorf("Equal = %t, want %t", got, tt.diff == "")
+			}
+		})
+	}
+}
+
+func mustBigInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid integer " + s)
+	}
+	return n
+}
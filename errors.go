@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrorKind classifies a ParseError so callers can tell, for example, input
+// that was cut short from input that is malformed.
+type ErrorKind int
+
+const (
+	// KindSyntax is input that breaks the JSON grammar. It is the default.
+	KindSyntax ErrorKind = iota
+
+	// KindUnexpectedEOF is input that ends in the middle of a value, such
+	// as an empty document, an unterminated string or an unclosed array.
+	KindUnexpectedEOF
+
+	// KindDepthLimit is nesting deeper than Parser.MaxDepth.
+	KindDepthLimit
+
+	// KindSizeLimit is input larger than Parser.MaxInputBytes or with more
+	// values than Parser.MaxValues.
+	KindSizeLimit
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindSyntax:
+		return "syntax error"
+	case KindUnexpectedEOF:
+		return "unexpected end of input"
+	case KindDepthLimit:
+		return "depth limit exceeded"
+	case KindSizeLimit:
+		return "size limit exceeded"
+	}
+	return "unknown error"
+}
+
+// Sentinel errors matching each ErrorKind with errors.Is. A ParseError of
+// kind KindUnexpectedEOF also matches io.ErrUnexpectedEOF.
+var (
+	ErrSyntax        = errors.New("syntax error")
+	ErrUnexpectedEOF = errors.New("unexpected end of input")
+	ErrDepthLimit    = errors.New("depth limit exceeded")
+	ErrSizeLimit     = errors.New("size limit exceeded")
+)
+
+// Is reports whether target is the sentinel error for the kind of e.
+func (e *ParseError) Is(target error) bool {
+	switch e.Kind {
+	case KindSyntax:
+		return target == ErrSyntax
+	case KindUnexpectedEOF:
+		return target == ErrUnexpectedEOF || target == io.ErrUnexpectedEOF
+	case KindDepthLimit:
+		return target == ErrDepthLimit
+	case KindSizeLimit:
+		return target == ErrSizeLimit
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParseErrorKind(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  ErrorKind
+		is    error
+	}{
+		{``, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`[1, 2`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`{"a": `, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`{"a"`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`"open`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`"\u12`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`tr`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`-`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`1.`, KindUnexpectedEOF, ErrUnexpectedEOF},
+		{`[1 2]`, KindSyntax, ErrSyntax},
+		{`{"a": tru}`, KindSyntax, ErrSyntax},
+		{`"\uZZZZ"`, KindSyntax, ErrSyntax},
+		{`[1] x`, KindSyntax, ErrSyntax},
+		{`[[[1]]]`, KindDepthLimit, ErrDepthLimit},
+		{`[1, 2, 3, 4]`, KindSizeLimit, ErrSizeLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.MaxDepth = 2
+			p.MaxValues = 4
+
+			_, err := p.Parse()
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			if perr.Kind != tt.kind {
+				t.Errorf("got kind %v, want %v", perr.Kind, tt.kind)
+			}
+
+			for _, sentinel := range []error{ErrSyntax, ErrUnexpectedEOF, ErrDepthLimit, ErrSizeLimit} {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.is; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+
+			if got, want := errors.Is(err, io.ErrUnexpectedEOF), tt.kind == KindUnexpectedEOF; got != want {
+				t.Errorf("errors.Is(err, io.ErrUnexpectedEOF) = %v, want %v", got, want)
+			}
+		})
+	}
+}
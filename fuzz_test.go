@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+var fuzzSeeds = []string{
+	`{"name": "John Doe", "age": 30, "verified": true, "friends": ["Jane", "James"], "address": {"city": "NY", "zip": null}}`,
+	``,
+	` `,
+	`[]`,
+	`{}`,
+	`[[[[]]]]`,
+	`-0.5e-10`,
+	`"esc\"aped é 😀"`,
+	`[1, 2,]`,
+	`{"a": 1,}`,
+	`{"a"`,
+	`[tru`,
+	`01`,
+	`1e`,
+	"\xEF\xBB\xBF[1]",
+	"\"\xff\"",
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		NewParserBytes(data).Parse()
+		Valid(data)
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		p := NewParserBytes(data)
+		p.UseNumber = true
+
+		v, err := p.Parse()
+		if err != nil {
+			return
+		}
+
+		out, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal(%#v): %v", v, err)
+		}
+
+		p = NewParserBytes(out)
+		p.UseNumber = true
+
+		got, err := p.Parse()
+		if err != nil {
+			t.Fatalf("re-parsing %s: %v", out, err)
+		}
+
+		if !reflect.DeepEqual(got, v) {
+			t.Errorf("round trip of %q: got %#v, want %#v", data, got, v)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+// IterateObject reads the object at the start of the input one member at a
+// time, without building a map. fn is called with each key and the offset
+// where its value starts. To decode the value fn calls p.ParseValue;
+// otherwise IterateObject skips over it after fn returns. Returning stop
+// ends the iteration early and leaves the rest of the object unread.
+func (p *Parser) IterateObject(fn func(key string, valueStart int) (stop bool, err error)) error {
+	if err := p.load(); err != nil {
+		return err
+	}
+	if err := p.iterateObject(fn); err != nil {
+		return p.locate(err)
+	}
+	return nil
+}
+
+func (p *Parser) iterateObject(fn func(key string, valueStart int) (stop bool, err error)) error {
+	if err := p.skipWhiteSpace(); err != nil {
+		return err
+	}
+	if p.pos >= len(p.input) || p.input[p.pos] != BeginObject {
+		return p.expected("'{'")
+	}
+
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return err
+	}
+	p.pos++
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return err
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == EndObject {
+		p.pos++
+		return nil
+	}
+
+	for {
+		if p.pos >= len(p.input) {
+			return &ParseError{msg: "unexpected end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+		}
+		if !p.isKeyStart(p.input[p.pos]) {
+			return p.badKey()
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		if err := p.skipWhiteSpace(); err != nil {
+			return err
+		}
+		if p.pos >= len(p.input) {
+			return &ParseError{msg: "expected ':' after object key, got end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+		}
+		if p.input[p.pos] != NameSeparator {
+			return p.expected("':' after object key")
+		}
+		p.pos++
+
+		if err := p.skipWhiteSpace(); err != nil {
+			return err
+		}
+
+		start := p.pos
+		stop, err := fn(key, start)
+		if err != nil || stop {
+			return err
+		}
+
+		// The value is still unread unless fn parsed it.
+		if p.pos == start {
+			if err := p.skipValue(); err != nil {
+				return err
+			}
+		}
+
+		done, err := p.parseSeparator(EndObject, "',' or '}'")
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// skipValue moves past the next value, checking it like Validate does but
+// without building the decoded result.
+func (p *Parser) skipValue() error {
+	validateOnly := p.validateOnly
+	p.validateOnly = true
+	_, err := p.parseValue()
+	p.validateOnly = validateOnly
+	return err
+}
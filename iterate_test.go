@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// wideObject returns an object with n filler members around the keys "id"
+// and "name".
+func wideObject(n int) string {
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i := 0; i < n; i++ {
+		if i == n/2 {
+			sb.WriteString(`"id": 42, `)
+		}
+		fmt.Fprintf(&sb, `"field%d": {"nested": [%d, "x\"y", null], "flag": true}, `, i, i)
+	}
+	sb.WriteString(`"name": "wide"}`)
+	return sb.String()
+}
+
+func TestIterateObject(t *testing.T) {
+	p := NewParser(wideObject(1000))
+
+	got := map[string]JSON{}
+	keys := 0
+	err := p.IterateObject(func(key string, valueStart int) (bool, error) {
+		keys++
+		if key != "id" && key != "name" {
+			return false, nil
+		}
+
+		value, _, err := p.ParseValue()
+		if err != nil {
+			return false, err
+		}
+		got[key] = value
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := map[string]JSON{"id": 42, "name": "wide"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if keys != 1002 {
+		t.Errorf("visited %d keys, want 1002", keys)
+	}
+}
+
+func TestIterateObjectStop(t *testing.T) {
+	input := `{"a": 1, "b": [2], "c": 3}`
+	p := NewParser(input)
+
+	var keys []string
+	err := p.IterateObject(func(key string, valueStart int) (bool, error) {
+		keys = append(keys, key)
+		if want := strings.Index(input, `"`+key+`"`) + 5; valueStart != want {
+			t.Errorf("key %s: got value start %d, want %d", key, valueStart, want)
+		}
+		return key == "b", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("got keys %v, want [a b]", keys)
+	}
+
+	errStop := errors.New("stop")
+	err = NewParser(input).IterateObject(func(string, int) (bool, error) { return false, errStop })
+	if err != errStop {
+		t.Errorf("got %v, want the callback's error", err)
+	}
+}
+
+func TestIterateObjectErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{`[1]`, 0},
+		{``, 0},
+		{`{"a" 1}`, 5},
+		{`{"a": [1, }`, 10},
+		{`{"a": 1 "b": 2}`, 8},
+		{`{"a": 1`, 7},
+		{`{1: 2}`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := NewParser(tt.input).IterateObject(func(string, int) (bool, error) { return false, nil })
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			if perr.pos != tt.pos {
+				t.Errorf("got error %q at %d, want position %d", perr.msg, perr.pos, tt.pos)
+			}
+		})
+	}
+
+	if err := NewParser(` { } `).IterateObject(func(string, int) (bool, error) {
+		t.Error("callback called for an empty object")
+		return false, nil
+	}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSkipValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		end   int
+	}{
+		{"object", `{"a": 1} ,`, 8},
+		{"nested object", `{"a": {"b": [1, {"c": "}"}]}, "d": {}}]`, 38},
+		{"array", `[1, [2, [3]], "]"] 4`, 18},
+		{"string", `"plain" "next"`, 7},
+		{"string with escaped quote", `"say \"hi\" \\" 1`, 15},
+		{"number", `-12.5e+3,`, 8},
+		{"true", `true]`, 4},
+		{"false", `false }`, 5},
+		{"null", `null,null`, 4},
+		{"leading whitespace", "  \n\t[] x", 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			if err := p.skipValue(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.pos != tt.end {
+				t.Errorf("got position %d, want %d (%q left)", p.pos, tt.end, tt.input[p.pos:])
+			}
+		})
+	}
+
+	for _, input := range []string{`{"a": }`, `[1, 2`, `"open`, `tru`, `{"a" 1}`, ``} {
+		if err := NewParser(input).skipValue(); err == nil {
+			t.Errorf("skipValue(%q): expected error", input)
+		}
+	}
+}
+
+func TestSkipValueDoesNotAllocate(t *testing.T) {
+	data := []byte(`{"a": [1, 2.5, "x\"y", {"b": null}], "c": "café", "d": true}`)
+	p := NewParserBytes(data)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		p.pos = 0
+		if err := p.skipValue(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("skipValue made %v allocations, want 0", allocs)
+	}
+}
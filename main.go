@@ -1,9 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // https://datatracker.ietf.org/doc/html/rfc8259#page-5
@@ -18,240 +27,1516 @@ const (
 
 type JSON interface{}
 
+// NullValue is the type of Null.
+type NullValue struct{}
+
+// Null is what an explicit JSON null decodes to when Parser.ExplicitNull is
+// set, so it can be told apart from a Go nil.
+var Null = NullValue{}
+
+// DuplicateKeyStrategy selects how Parser handles an object key that
+// appears more than once.
+type DuplicateKeyStrategy int
+
+const (
+	// DuplicateKeepLast keeps the value of the last occurrence of a key. It
+	// is the default.
+	DuplicateKeepLast DuplicateKeyStrategy = iota
+
+	// DuplicateKeepFirst keeps the value of the first occurrence of a key
+	// and skips the values of later ones.
+	DuplicateKeepFirst
+
+	// DuplicateError makes a repeated key a parse error.
+	DuplicateError
+)
+
 type Parser struct {
-	input string
-	pos   int
+	input  []byte
+	pos    int
+	reader io.Reader
+
+	// NumberMode selects the Go type numbers decode to.
+	NumberMode NumberMode
+
+	// UseNumber makes the parser return numbers as Number instead of
+	// int or float64. It is the same as setting NumberMode to
+	// NumberUseNumber.
+	UseNumber bool
+
+	// LargeIntAsNumber makes integers too large for int64 decode as Number
+	// instead of float64, so no digits are lost.
+	LargeIntAsNumber bool
+
+	// MaxDepth limits how deeply objects and arrays may nest. Zero means
+	// no limit.
+	MaxDepth int
+	depth    int
+
+	// MaxInputBytes limits the size of the input in bytes, and MaxValues
+	// the number of values parsed from it, counting every object, array,
+	// member value and element. Zero means no limit.
+	MaxInputBytes int
+	MaxValues     int
+	values        int
+
+	// DuplicateKeys selects what happens when an object key repeats.
+	DuplicateKeys DuplicateKeyStrategy
+
+	// DisallowDuplicateKeys makes a repeated object key an error instead of
+	// the last value winning. It is the same as setting DuplicateKeys to
+	// DuplicateError.
+	DisallowDuplicateKeys bool
+
+	// PreserveOrder makes the parser return objects as *OrderedMap so the
+	// original key order is kept.
+	PreserveOrder bool
+
+	// ExplicitNull makes the parser return Null for JSON null instead of nil.
+	ExplicitNull bool
+
+	// PreserveScalars makes the parser return every string, number, true,
+	// false and null as a RawValue holding its source text, so marshaling
+	// the tree reproduces each unmodified scalar byte for byte. Combine it
+	// with PreserveOrder to keep the key order too. A scalar written in the
+	// syntax of AllowInfNaN, AllowHexNumbers or AllowSingleQuotes is not
+	// valid JSON, so it is returned as its plain decoded value instead.
+	PreserveScalars bool
+
+	// AllowComments makes the parser skip // line and /* block */ comments
+	// wherever whitespace is allowed.
+	AllowComments bool
+
+	// AllowTrailingCommas accepts a comma directly before a closing ] or }.
+	AllowTrailingCommas bool
+
+	// AllowExtraWhitespace treats the runes in ExtraWhitespace as
+	// insignificant whitespace too. ExtraWhitespace defaults to no-break
+	// space (U+00A0) and the byte-order mark (U+FEFF).
+	AllowExtraWhitespace bool
+	ExtraWhitespace      []rune
+
+	// InternKeys makes repeated object keys share one string instead of
+	// each being allocated afresh, which saves memory on arrays of records
+	// with the same fields at the cost of a map lookup per key.
+	InternKeys bool
+	keys       map[string]string
+
+	// Reviver, like the reviver of JavaScript's JSON.parse, is called with
+	// each member and element once it has been parsed, and with the key ""
+	// for the top-level value. Elements are passed their index as key. The
+	// value it returns replaces the parsed one, and returning Omit leaves
+	// the member or element out.
+	Reviver func(key string, value JSON) JSON
+
+	// AllowInfNaN accepts the JavaScript spellings NaN, Infinity and
+	// -Infinity, decoding them as float64.
+	AllowInfNaN bool
+
+	// AllowHexNumbers accepts JSON5 hexadecimal integers such as 0xFF and
+	// -0x10, decoding them like the equivalent decimal integer.
+	AllowHexNumbers bool
+
+	// AllowSingleQuotes accepts strings and object keys delimited by ' as
+	// well as ", with the same escapes plus \' for a literal quote.
+	AllowSingleQuotes bool
+
+	// AllowUnquotedKeys accepts object keys written as bare identifiers
+	// matching [A-Za-z_$][A-Za-z0-9_$]*, as in {name: "x"}.
+	AllowUnquotedKeys bool
+
+	// validateOnly checks the grammar without building decoded values.
+	validateOnly bool
+
+	ctx   context.Context
+	steps int
+
+	// spanParent is the node receiving spans of values parsed by
+	// ParseWithSpans, and spanKey the object key of the next one.
+	spanParent *SpanNode
+	spanKey    string
+
+	// path holds the object keys and array indices leading to the value
+	// being parsed, for ParseError.Path.
+	path []pathSegment
+
+	// partial makes ParsePartial keep the objects and arrays parsed before
+	// an error.
+	partial bool
+
+	// collectErrors makes ParseAll record errors in errs and carry on.
+	collectErrors bool
+	errs          []error
+}
+
+// utf8BOM is the byte-order mark some editors write at the start of a file.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// OmitValue is the type of Omit.
+type OmitValue struct{}
+
+// Omit is what a Parser.Reviver returns to drop a member or element.
+var Omit = OmitValue{}
+
+// contextCheckInterval is how many values ParseContext parses between
+// checks for cancellation.
+const contextCheckInterval = 1024
+
+type ParseError struct {
+	msg string
+	pos int
+
+	// Line and Column are the 1-based coordinates of pos, filled in by Parse.
+	Line   int
+	Column int
+
+	// Path is the JSON path of the innermost value containing the error,
+	// such as $.address.city or $.friends[1].
+	Path string
+
+	// Kind says what sort of error this is. It can also be tested with
+	// errors.Is and the sentinel errors such as ErrUnexpectedEOF.
+	Kind ErrorKind
+}
+
+func (e *ParseError) Error() string {
+	where := fmt.Sprintf("position %d", e.pos)
+	if e.Line != 0 {
+		where = fmt.Sprintf("line %d, column %d (position %d)", e.Line, e.Column, e.pos)
+	}
+
+	// Errors in the top-level value are located well enough by position.
+	if e.Path != "" && e.Path != "$" {
+		where += " in " + e.Path
+	}
+
+	return fmt.Sprintf("Parse error at %s: %s", where, e.msg)
+}
+
+// pathSegment is an object key, or an array index when index is not -1.
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// setPath records the current path in err unless a more deeply nested
+// value already did.
+func (p *Parser) setPath(err error) {
+	if perr, ok := err.(*ParseError); ok && perr.Path == "" {
+		perr.Path = formatPath(p.path)
+	}
+}
+
+func formatPath(path []pathSegment) string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+
+	for _, seg := range path {
+		switch {
+		case seg.index >= 0:
+			fmt.Fprintf(&sb, "[%d]", seg.index)
+		case isIdentifier(seg.key):
+			sb.WriteByte('.')
+			sb.WriteString(seg.key)
+		default:
+			fmt.Fprintf(&sb, "[%q]", seg.key)
+		}
+	}
+
+	return sb.String()
+}
+
+// isIdentifier reports whether key can be written after a dot in a path.
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipBOM steps over a UTF-8 byte-order mark at the very start of the input.
+func (p *Parser) skipBOM() {
+	if p.pos == 0 && bytes.HasPrefix(p.input, utf8BOM) {
+		p.pos = len(utf8BOM)
+	}
+}
+
+// locate fills in the line and column of a ParseError from the parser input.
+func (p *Parser) locate(err error) error {
+	perr, ok := err.(*ParseError)
+	if !ok {
+		return err
+	}
+
+	p.setPath(perr)
+
+	end := perr.pos
+	if end > len(p.input) {
+		end = len(p.input)
+	}
+
+	lineStart := bytes.LastIndexByte(p.input[:end], '\n') + 1
+	perr.Line = bytes.Count(p.input[:end], []byte{'\n'}) + 1
+	perr.Column = utf8.RuneCount(p.input[lineStart:end]) + 1
+
+	return perr
+}
+
+func NewParser(input string) *Parser {
+	return NewParserBytes([]byte(input))
+}
+
+// NewParserBytes returns a parser that reads data in place, without copying
+// it. data must not be modified until parsing is done.
+func NewParserBytes(data []byte) *Parser {
+	return &Parser{input: data}
+}
+
+// NewParserFromReader returns a parser that reads its input from r. It is a
+// convenience for callers holding an io.Reader: the reader is drained into
+// memory the first time Parse is called, so the whole document must fit in
+// RAM, bounded by MaxInputBytes if set. To walk inputs too large for that,
+// use NewDecoder, whose Decoder reads the stream one token at a time and
+// holds only the current token in memory.
+func NewParserFromReader(r io.Reader) *Parser {
+	return &Parser{reader: r}
+}
+
+// Reset points the parser at a new input so it can be reused for another
+// document. Options such as UseNumber and MaxDepth are kept.
+func (p *Parser) Reset(input string) {
+	p.input = []byte(input)
+	p.pos = 0
+	p.reader = nil
+	p.depth = 0
+	p.steps = 0
+	p.values = 0
+	p.path = p.path[:0]
+	p.errs = nil
+}
+
+// Position returns the byte offset the parser has reached in its input. It is
+// safe to call from Visitor callbacks or a Reviver to report progress while
+// a large document is parsed.
+func (p *Parser) Position() int {
+	return p.pos
+}
+
+// Progress returns Position as a fraction of the input length, from 0 before
+// parsing starts to 1 once the whole input has been consumed. Input from
+// NewParserFromReader only has a length once parsing has begun.
+func (p *Parser) Progress() float64 {
+	if len(p.input) == 0 {
+		return 0
+	}
+	return float64(p.pos) / float64(len(p.input))
+}
+
+// load drains the reader given to NewParserFromReader, if any, and steps
+// over a leading byte-order mark.
+func (p *Parser) load() error {
+	if p.reader != nil {
+		r := p.reader
+		if p.MaxInputBytes > 0 {
+			// Read one byte past the limit so going over it can be seen
+			// without draining the whole reader.
+			r = io.LimitReader(r, int64(p.MaxInputBytes)+1)
+		}
+
+		data, err := io.ReadAll(r)
+		p.reader = nil
+		if err != nil {
+			return err
+		}
+		p.input = data
+	}
+
+	if p.MaxInputBytes > 0 && len(p.input) > p.MaxInputBytes {
+		return p.locate(&ParseError{msg: fmt.Sprintf("input exceeds %d bytes", p.MaxInputBytes), pos: p.MaxInputBytes, Kind: KindSizeLimit})
+	}
+
+	p.skipBOM()
+	return nil
+}
+
+// Unmarshal parses data as a single JSON document.
+func Unmarshal(data []byte) (JSON, error) {
+	return NewParserBytes(data).Parse()
+}
+
+// ParseFile reads the file at path through NewParserFromReader and parses it
+// as a single JSON document. Errors reading the file are wrapped with its
+// path; syntax errors are returned as a *ParseError. The file is always
+// closed before ParseFile returns.
+func ParseFile(path string) (JSON, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	v, err := NewParserFromReader(f).Parse()
+	if _, ok := err.(*ParseError); err != nil && !ok {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return v, err
+}
+
+// Parse parses the input as a single JSON document. If the document is
+// followed by anything other than whitespace, Parse reports an error at the
+// first trailing byte but still returns the value it parsed.
+func (p *Parser) Parse() (JSON, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return nil, p.locate(err)
+	}
+	if p.pos >= len(p.input) {
+		return nil, p.locate(&ParseError{msg: "unexpected end of input: empty document", pos: p.pos, Kind: KindUnexpectedEOF})
+	}
+
+	value, err := p.parseValue()
+
+	if err != nil {
+		return p.partialResult(value), p.locate(err)
+	}
+
+	if p.Reviver != nil {
+		if value = p.Reviver("", value); value == Omit {
+			p.omitSpan("", nil)
+			value = nil
+		}
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return nil, p.locate(err)
+	}
+	if p.pos < len(p.input) {
+		return value, p.locate(&ParseError{msg: "unexpected trailing characters", pos: p.pos})
+	}
+
+	return value, nil
+}
+
+// ParseContext is like Parse but stops with ctx.Err() once ctx is cancelled
+// or its deadline passes.
+func (p *Parser) ParseContext(ctx context.Context) (JSON, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.ctx = ctx
+	defer func() { p.ctx = nil }()
+
+	return p.Parse()
+}
+
+// ParsePartial parses the input like Parse, but when it fails it returns the
+// objects and arrays built up to the error along with it, to show how far
+// parsing got. A container that was cut short holds the members or elements
+// that were complete before the error, plus the partial container holding
+// the error itself.
+func (p *Parser) ParsePartial() (JSON, error) {
+	p.partial = true
+	defer func() { p.partial = false }()
+
+	return p.Parse()
+}
+
+// ParseValue parses the next value in the input and returns it together with
+// the offset just past it. Anything after the value is left for the next
+// call, so a stream of concatenated documents can be read one at a time.
+// Once only whitespace remains ParseValue returns io.EOF.
+func (p *Parser) ParseValue() (JSON, int, error) {
+	if err := p.load(); err != nil {
+		return nil, p.pos, err
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return nil, p.pos, p.locate(err)
+	}
+	if p.pos >= len(p.input) {
+		return nil, p.pos, io.EOF
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, p.pos, p.locate(err)
+	}
+
+	return value, p.pos, nil
+}
+
+func (p *Parser) parseValue() (JSON, error) {
+	if p.ctx != nil {
+		p.steps++
+		if p.steps%contextCheckInterval == 0 {
+			if err := p.ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return nil, err
+	}
+
+	if p.pos >= len(p.input) {
+		return nil, &ParseError{msg: "unexpected end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+	}
+
+	if err := p.countValue(); err != nil {
+		return nil, err
+	}
+
+	if p.spanParent != nil {
+		return p.parseValueWithSpan()
+	}
+
+	return p.parsePreserved()
+}
+
+// parsePreserved parses the value at the current position, wrapping a
+// scalar in a RawValue when PreserveScalars is set.
+func (p *Parser) parsePreserved() (JSON, error) {
+	if p.PreserveScalars && !p.validateOnly {
+		if c := p.input[p.pos]; c != BeginObject && c != BeginArray {
+			start := p.pos
+			value, err := p.parseValueAt()
+			if err != nil {
+				return nil, err
+			}
+			raw := p.input[start:p.pos]
+			if (p.AllowInfNaN || p.AllowHexNumbers || p.AllowSingleQuotes) && !Valid(raw) {
+				return value, nil
+			}
+			return RawValue{Value: value, Raw: string(raw)}, nil
+		}
+	}
+
+	return p.parseValueAt()
+}
+
+// countValue records that another value starts at the current position,
+// failing once MaxValues is exceeded.
+func (p *Parser) countValue() error {
+	p.values++
+	if p.MaxValues > 0 && p.values > p.MaxValues {
+		return &ParseError{msg: fmt.Sprintf("document has more than %d values", p.MaxValues), pos: p.pos, Kind: KindSizeLimit}
+	}
+	return nil
+}
+
+// parseValueAt parses the value starting at the current, non-whitespace
+// position.
+func (p *Parser) parseValueAt() (JSON, error) {
+	cur := p.input[p.pos]
+
+	if p.AllowInfNaN {
+		if literal := nonFiniteLiteral(p.input[p.pos:]); literal != "" {
+			return p.parseLiteral(literal)
+		}
+	}
+
+	if cur == '\'' && p.AllowSingleQuotes {
+		return p.parseString()
+	}
+
+	switch cur {
+	case BeginObject:
+		return p.parseObject()
+	case '"':
+		return p.parseString()
+	case BeginArray:
+		arr, err := p.parseArray()
+		if p.validateOnly {
+			// Avoid boxing the unused slice header.
+			return nil, err
+		}
+		return arr, err
+	case 'f':
+		return p.parseLiteral("false")
+	case 't':
+		return p.parseLiteral("true")
+	case 'n':
+		return p.parseLiteral("null")
+	case 45, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57:
+		return p.parseNumber()
+	default:
+		return nil, &ParseError{msg: fmt.Sprintf("unexpected character %s", quoteChar(p.input, p.pos)), pos: p.pos}
+	}
+}
+
+// expected reports that the byte at the current position is not one of the
+// tokens the grammar allows here.
+func (p *Parser) expected(tokens string) error {
+	if p.pos >= len(p.input) {
+		return &ParseError{msg: fmt.Sprintf("unexpected end of input, expected %s", tokens), pos: p.pos, Kind: KindUnexpectedEOF}
+	}
+	return &ParseError{msg: fmt.Sprintf("expected %s but found %s", tokens, quoteChar(p.input, p.pos)), pos: p.pos}
+}
+
+// quoteChar describes the character starting at input[pos] for an error
+// message, quoted like %q, or as a hex byte when it is not valid UTF-8.
+func quoteChar(input []byte, pos int) string {
+	r, size := utf8.DecodeRune(input[pos:])
+	if r == utf8.RuneError && size <= 1 {
+		return fmt.Sprintf("%#02x", input[pos])
+	}
+	return strconv.QuoteRune(r)
+}
+
+// mismatched reports a closing bracket at the current position that does not
+// close the innermost open container, which expects closer.
+func (p *Parser) mismatched(closer byte) error {
+	return &ParseError{msg: fmt.Sprintf("mismatched bracket: expected '%c' but found '%c'", closer, p.input[p.pos]), pos: p.pos}
+}
+
+// enter records descending into an object or array, failing once MaxDepth
+// is exceeded. Callers must pair it with a deferred p.leave().
+func (p *Parser) enter() error {
+	p.depth++
+	if p.MaxDepth > 0 && p.depth > p.MaxDepth {
+		return &ParseError{msg: "maximum nesting depth exceeded", pos: p.pos, Kind: KindDepthLimit}
+	}
+	return nil
+}
+
+func (p *Parser) leave() {
+	p.depth--
+}
+
+func (p *Parser) parseObject() (JSON, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+
+	var obj map[string]JSON
+	if !p.validateOnly {
+		obj = make(map[string]JSON)
+	}
+	var ordered *OrderedMap
+	var result JSON = obj
+	if p.PreserveOrder {
+		ordered = NewOrderedMap()
+		result = ordered
+	}
+	p.pos++
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return p.partialResult(result), err
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == EndObject {
+		p.pos++
+	} else {
+		for {
+			if err := p.parseMember(obj, ordered); err != nil && !p.recoverError(err) {
+				return p.partialResult(result), err
+			}
+
+			done, err := p.parseSeparator(EndObject, "',' or '}'")
+			if err != nil {
+				return p.partialResult(result), err
+			}
+
+			if done {
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// parseMember parses one "key": value pair of an object into obj, or into
+// ordered when PreserveOrder is set.
+func (p *Parser) parseMember(obj map[string]JSON, ordered *OrderedMap) error {
+	if p.pos >= len(p.input) {
+		return &ParseError{msg: "unexpected end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+	}
+
+	if p.input[p.pos] == EndArray {
+		return p.mismatched(EndObject)
+	}
+
+	if !p.isKeyStart(p.input[p.pos]) {
+		return p.badKey()
+	}
+
+	keyPos := p.pos
+	key, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	exists := false
+	if ordered != nil {
+		_, exists = ordered.Get(key)
+	} else {
+		_, exists = obj[key]
+	}
+
+	strategy := p.DuplicateKeys
+	if p.DisallowDuplicateKeys {
+		strategy = DuplicateError
+	}
+
+	if exists && strategy == DuplicateError {
+		return &ParseError{msg: fmt.Sprintf("duplicate key %q", key), pos: keyPos}
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return err
+	}
+
+	if p.pos >= len(p.input) {
+		return &ParseError{msg: "expected ':' after object key, got end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+	}
+
+	if p.input[p.pos] != NameSeparator {
+		return p.expected("':' after object key")
+	}
+	p.pos++
+
+	p.spanKey = key
+	p.path = append(p.path, pathSegment{key: key, index: -1})
+	skip := exists && strategy == DuplicateKeepFirst
+	var prevSpan *SpanNode
+	if p.spanParent != nil {
+		prevSpan = p.spanParent.Members[key]
+	}
+	var value JSON
+	if skip {
+		err = p.skipDuplicate()
+	} else {
+		value, err = p.parseValue()
+	}
+	if err != nil {
+		p.setPath(err)
+	}
+	p.path = p.path[:len(p.path)-1]
+	if err != nil {
+		if p.partialResult(value) != nil {
+			p.storeMember(obj, ordered, key, value)
+		}
+		return err
+	}
+
+	if skip {
+		return nil
+	}
+
+	if p.Reviver != nil && !p.validateOnly {
+		if value = p.Reviver(key, value); value == Omit {
+			p.omitSpan(key, prevSpan)
+			return nil
+		}
+	}
+
+	p.storeMember(obj, ordered, key, value)
+	return nil
+}
+
+// skipDuplicate skips the value of a repeated key under DuplicateKeepFirst,
+// leaving it out of the spans too.
+func (p *Parser) skipDuplicate() error {
+	parent := p.spanParent
+	p.spanParent = nil
+	err := p.skipValue()
+	p.spanParent = parent
+	return err
+}
+
+// storeMember sets key to value in obj, or in ordered when PreserveOrder is
+// set.
+func (p *Parser) storeMember(obj map[string]JSON, ordered *OrderedMap, key string, value JSON) {
+	switch {
+	case p.validateOnly:
+	case ordered != nil:
+		ordered.Set(key, value)
+	default:
+		obj[key] = value
+	}
+}
+
+// maxInternedKeys bounds the InternKeys cache so a document with many
+// distinct keys cannot grow it without limit.
+const maxInternedKeys = 4096
+
+// parseKey parses an object key, returning an earlier copy of the same key
+// when InternKeys is set.
+func (p *Parser) parseKey() (string, error) {
+	if !p.isQuote(p.input[p.pos]) {
+		return p.parseBareKey(), nil
+	}
+
+	if !p.InternKeys || p.validateOnly {
+		return p.parseString()
+	}
+
+	// A key of printable ASCII without escapes is valid exactly when it is
+	// closed, so if it matches a cached one its raw bytes can be looked up
+	// directly. Anything else, such as a control character that a cached
+	// key decoded from an escape, goes through parseString.
+	quote := p.input[p.pos]
+	start := p.pos + 1
+	end := start
+	for end < len(p.input) {
+		if c := p.input[end]; c == quote || c == '\\' || c < 0x20 || c >= utf8.RuneSelf {
+			break
+		}
+		end++
+	}
+
+	if end < len(p.input) && p.input[end] == quote {
+		if key, ok := p.keys[string(p.input[start:end])]; ok {
+			p.pos = end + 1
+			return key, nil
+		}
+	}
+
+	key, err := p.parseString()
+	if err != nil {
+		return "", err
+	}
+
+	p.intern(key)
+	return key, nil
+}
+
+// parseBareKey parses an AllowUnquotedKeys identifier key. The caller has
+// checked that it starts with an identifier character.
+func (p *Parser) parseBareKey() string {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.input) && isBareKeyChar(p.input[p.pos], true) {
+		p.pos++
+	}
+
+	if p.validateOnly {
+		return ""
+	}
+	if key, ok := p.keys[string(p.input[start:p.pos])]; ok {
+		return key
+	}
+
+	key := string(p.input[start:p.pos])
+	if p.InternKeys {
+		p.intern(key)
+	}
+	return key
+}
+
+// intern adds key to the InternKeys cache while it has room.
+func (p *Parser) intern(key string) {
+	if p.keys == nil {
+		p.keys = make(map[string]string)
+	}
+	if len(p.keys) < maxInternedKeys {
+		p.keys[key] = key
+	}
+}
+
+// isKeyStart reports whether c can begin an object key.
+func (p *Parser) isKeyStart(c byte) bool {
+	return p.isQuote(c) || (p.AllowUnquotedKeys && isBareKeyChar(c, false))
+}
+
+// badKey reports that the current position does not start an object key.
+func (p *Parser) badKey() error {
+	if p.AllowUnquotedKeys {
+		return &ParseError{msg: "object key must be a string or identifier", pos: p.pos}
+	}
+	return &ParseError{msg: "object key must be a string", pos: p.pos}
+}
+
+// isBareKeyChar reports whether c may appear in an unquoted key, where
+// digits are only allowed after the first character.
+func isBareKeyChar(c byte, digits bool) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', c == '_', c == '$':
+		return true
+	case '0' <= c && c <= '9':
+		return digits
+	}
+	return false
+}
+
+// parseSeparator consumes the ',' or closing bracket that follows an object
+// member or array element, reporting done once closer has been consumed.
+func (p *Parser) parseSeparator(closer byte, tokens string) (bool, error) {
+	for {
+		if err := p.skipWhiteSpace(); err != nil {
+			return false, err
+		}
+
+		if p.pos >= len(p.input) {
+			return false, p.expected(tokens)
+		}
+
+		switch p.input[p.pos] {
+		case closer:
+			p.pos++
+			return true, nil
+
+		case ValueSeparator:
+			p.pos++
+			if err := p.skipWhiteSpace(); err != nil {
+				return false, err
+			}
+
+			if p.pos < len(p.input) && p.input[p.pos] == closer {
+				if !p.AllowTrailingCommas {
+					err := &ParseError{msg: "unexpected trailing comma", pos: p.pos}
+					if !p.recoverError(err) {
+						return false, err
+					}
+				}
+				p.pos++
+				return true, nil
+			}
+			return false, nil
+
+		default:
+			err := p.expected(tokens)
+			if c := p.input[p.pos]; c == EndObject || c == EndArray {
+				err = p.mismatched(closer)
+			}
+			if !p.recoverError(err) {
+				return false, err
+			}
+
+			// A mismatched closer after recovery belongs to an enclosing
+			// container, so leave it for that one to consume.
+			if c := p.input[p.pos]; c != closer && c != ValueSeparator {
+				return true, nil
+			}
+		}
+	}
 }
 
-type ParseError struct {
-	msg string
-	pos int
+// isQuote reports whether c opens a string: always ", and ' too when
+// AllowSingleQuotes is set.
+func (p *Parser) isQuote(c byte) bool {
+	return c == '"' || (c == '\'' && p.AllowSingleQuotes)
 }
 
-func (e *ParseError) Error() string {
-	return fmt.Sprintf("Parse error at position %d: %s", e.pos, e.msg)
+// https://datatracker.ietf.org/doc/html/rfc8259#section-7
+var escapeChars = map[byte]byte{
+	'"':  '"',
+	'\\': '\\',
+	'/':  '/',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
 }
 
-func NewParser(input string) *Parser {
-	return &Parser{input, 0}
+func (p *Parser) parseString() (string, error) {
+	start := p.pos
+	quote := p.input[p.pos]
+	p.pos++
+	var sb strings.Builder
+
+	for {
+		if p.pos >= len(p.input) {
+			return "", &ParseError{msg: "unterminated string literal", pos: start, Kind: KindUnexpectedEOF}
+		}
+
+		if p.input[p.pos] == quote {
+			break
+		}
+
+		if p.input[p.pos] < 0x20 {
+			return "", &ParseError{msg: "unescaped control character in string", pos: p.pos}
+		}
+
+		if p.input[p.pos] >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(p.input[p.pos:])
+			if r == utf8.RuneError && size == 1 {
+				return "", &ParseError{msg: "invalid UTF-8 in string", pos: p.pos}
+			}
+			if !p.validateOnly {
+				sb.Write(p.input[p.pos : p.pos+size])
+			}
+			p.pos += size
+			continue
+		}
+
+		if p.input[p.pos] != '\\' {
+			if !p.validateOnly {
+				sb.WriteByte(p.input[p.pos])
+			}
+			p.pos++
+			continue
+		}
+
+		if p.pos+1 >= len(p.input) {
+			return "", &ParseError{msg: "unterminated string literal", pos: start, Kind: KindUnexpectedEOF}
+		}
+
+		if p.input[p.pos+1] == 'u' {
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return "", err
+			}
+			if !p.validateOnly {
+				sb.WriteRune(r)
+			}
+			continue
+		}
+
+		escaped, ok := escapeChars[p.input[p.pos+1]]
+		if !ok && p.AllowSingleQuotes && p.input[p.pos+1] == '\'' {
+			escaped, ok = '\'', true
+		}
+		if !ok {
+			return "", &ParseError{msg: fmt.Sprintf("invalid escape character %q", p.input[p.pos+1]), pos: p.pos}
+		}
+
+		if !p.validateOnly {
+			sb.WriteByte(escaped)
+		}
+		p.pos += 2
+	}
+
+	p.pos++
+
+	return sb.String(), nil
 }
 
-func (p *Parser) Parse() (JSON, error) {
-	if len(p.input) <= 0 {
-		fmt.Println("Empty String")
-		return nil, nil
+// parseUnicodeEscape decodes a \uXXXX escape starting at the backslash,
+// combining a UTF-16 surrogate pair into a single rune.
+func (p *Parser) parseUnicodeEscape() (rune, error) {
+	start := p.pos
+	r1, err := p.readHex4()
+	if err != nil {
+		return 0, err
 	}
 
-	p.skipWhiteSpace()
-	if p.pos >= len(p.input) {
-		fmt.Println("Empty string")
-		return nil, nil
+	if !utf16.IsSurrogate(r1) {
+		return r1, nil
 	}
 
-	value, err := p.parseValue()
+	if r1 >= 0xDC00 || !bytes.HasPrefix(p.input[p.pos:], []byte(`\u`)) {
+		return 0, &ParseError{msg: fmt.Sprintf("lone surrogate %U", r1), pos: start}
+	}
 
+	r2, err := p.readHex4()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	p.skipWhiteSpace()
-	if p.pos < len(p.input) {
-		fmt.Println("Trailing character at the end")
-		return nil, err
+	r := utf16.DecodeRune(r1, r2)
+	if r == unicode.ReplacementChar {
+		return 0, &ParseError{msg: fmt.Sprintf("invalid surrogate pair %U %U", r1, r2), pos: start}
 	}
 
-	return value, nil
+	return r, nil
 }
 
-// TODO: Escape characters, String with new line, Large numbers
-func (p *Parser) parseValue() (JSON, error) {
-	p.skipWhiteSpace()
+// readHex4 consumes a single \uXXXX escape and returns its code unit. The
+// escape must be followed by exactly four hex digits; anything else, including
+// the input ending early, is reported at the backslash.
+func (p *Parser) readHex4() (rune, error) {
+	start := p.pos
+	p.pos += 2
 
-	cur := p.input[p.pos]
+	var r rune
+	for i := 0; i < 4; i++ {
+		if p.pos >= len(p.input) {
+			return 0, &ParseError{msg: "invalid \\u escape: expected 4 hex digits", pos: start, Kind: KindUnexpectedEOF}
+		}
+		if hexValue(p.input[p.pos]) < 0 {
+			return 0, &ParseError{msg: "invalid \\u escape: expected 4 hex digits", pos: start}
+		}
+		r = r<<4 | rune(hexValue(p.input[p.pos]))
+		p.pos++
+	}
 
-	switch cur {
-	case BeginObject:
-		return p.parseObject()
-	case '"':
-		return p.parseString()
-	case BeginArray:
-		return p.parseArray()
-	case 'f':
-		return p.parseLiteral("false")
-	case 't':
-		return p.parseLiteral("true")
-	case 'n':
-		return p.parseLiteral("null")
-	case 45, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57:
-		return p.parseNumber()
-	default:
-		return nil, nil
+	return r, nil
+}
+
+// hexValue returns the value of the hex digit c, or -1 if c is not one.
+func hexValue(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'F':
+		return int(c-'A') + 10
 	}
+	return -1
 }
 
-func (p *Parser) parseObject() (JSON, error) {
-	obj := make(map[string]JSON)
+func (p *Parser) parseArray() ([]interface{}, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+
+	arr := make([]interface{}, 0)
 	p.pos++
 
-	for {
-		p.skipWhiteSpace()
+	if err := p.skipWhiteSpace(); err != nil {
+		return p.partialArray(arr), err
+	}
 
-		if p.pos >= len(p.input) {
-			return nil, &ParseError{msg: "unexpected end of input", pos: p.pos}
-		}
+	if p.pos < len(p.input) && p.input[p.pos] == EndArray {
+		p.pos++
+		return arr, nil
+	}
 
-		if p.input[p.pos] == EndObject {
-			p.pos++
-			return obj, nil
+	for i := 0; ; i++ {
+		var value JSON
+		var err error
+		switch {
+		case p.pos < len(p.input) && p.input[p.pos] == EndObject:
+			err = p.mismatched(EndArray)
+		case p.pos < len(p.input) && p.input[p.pos] == ValueSeparator:
+			err = p.emptyElement(i)
+		default:
+			p.path = append(p.path, pathSegment{index: i})
+			value, err = p.parseValue()
+			if err != nil {
+				p.setPath(err)
+			}
+			p.path = p.path[:len(p.path)-1]
 		}
-
-		key, err := p.parseString()
-		if err != nil {
-			return nil, err
+		if err != nil && !p.recoverError(err) {
+			if p.partialResult(value) != nil {
+				arr = append(arr, value)
+			}
+			return p.partialArray(arr), err
 		}
 
-		p.skipWhiteSpace()
+		if err == nil && p.Reviver != nil && !p.validateOnly {
+			if value = p.Reviver(strconv.Itoa(i), value); value == Omit {
+				p.omitSpan("", nil)
+			}
+		}
 
-		if p.input[p.pos] != NameSeparator {
-			return nil, &ParseError{msg: "expected : after key", pos: p.pos}
+		if err == nil && !p.validateOnly && value != Omit {
+			arr = append(arr, value)
 		}
-		p.pos++
 
-		value, err := p.parseValue()
+		done, err := p.parseSeparator(EndArray, "',' or ']'")
 		if err != nil {
-			return nil, err
+			return p.partialArray(arr), err
 		}
 
-		obj[key] = value
+		if done {
+			return arr, nil
+		}
+	}
+}
 
-		p.skipWhiteSpace()
+// partialResult returns v if it is an object or array that ParsePartial
+// should keep although parsing it failed, or nil otherwise.
+func (p *Parser) partialResult(v JSON) JSON {
+	if !p.partial || p.validateOnly {
+		return nil
+	}
 
-		if p.input[p.pos] == EndObject {
-			continue
+	switch c := v.(type) {
+	case map[string]JSON:
+		if c != nil {
+			return c
 		}
-
-		if p.input[p.pos] != ValueSeparator {
-			return nil, &ParseError{msg: "expected , after", pos: p.pos}
+	case *OrderedMap:
+		if c != nil {
+			return c
 		}
+	case []interface{}:
+		if c != nil {
+			return c
+		}
+	}
+	return nil
+}
 
-		p.pos++
+// partialArray is partialResult for an array.
+func (p *Parser) partialArray(arr []interface{}) []interface{} {
+	if !p.partial || p.validateOnly {
+		return nil
 	}
+	return arr
 }
 
-func (p *Parser) parseString() (string, error) {
-	p.pos++
-	start := p.pos
+// emptyElement reports a ',' at the current position where the i'th array
+// element should start.
+func (p *Parser) emptyElement(i int) error {
+	if i == 0 {
+		return &ParseError{msg: "unexpected ',' before first array element", pos: p.pos}
+	}
+	return &ParseError{msg: "unexpected ',' (empty array element)", pos: p.pos}
+}
 
-	for p.input[p.pos] != '"' {
+func (p *Parser) parseLiteral(literal string) (interface{}, error) {
+	for i := 0; i < len(literal); i++ {
+		if p.pos >= len(p.input) {
+			return nil, &ParseError{msg: fmt.Sprintf("invalid literal: expected '%c' in '%s'", literal[i], literal), pos: p.pos, Kind: KindUnexpectedEOF}
+		}
+		if p.input[p.pos] != literal[i] {
+			return nil, &ParseError{msg: fmt.Sprintf("invalid literal: expected '%c' in '%s'", literal[i], literal), pos: p.pos}
+		}
 		p.pos++
 	}
 
-	p.pos++
+	if p.pos < len(p.input) {
+		switch c := p.input[p.pos]; c {
+		case ValueSeparator, EndArray, EndObject, ' ', '\n', '\t', '\r':
+		default:
+			if c < 0x20 {
+				return nil, &ParseError{msg: "invalid control character in input", pos: p.pos}
+			}
+			if (c != '/' || !p.AllowComments) && p.extraWhitespace() == 0 {
+				return nil, &ParseError{msg: fmt.Sprintf("invalid literal: unexpected %q after '%s'", c, literal), pos: p.pos}
+			}
+		}
+	}
+
+	switch literal {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
 
-	return p.input[start : p.pos-1], nil
+	if p.ExplicitNull {
+		return Null, nil
+	}
+	return nil, nil
 }
 
-func (p *Parser) parseArray() ([]interface{}, error) {
-	arr := make([]interface{}, 0)
-	p.pos++
+// nonFiniteLiteral returns which of the AllowInfNaN literals NaN, Infinity
+// and -Infinity rest starts with, judging by its first bytes.
+func nonFiniteLiteral(rest []byte) string {
+	switch {
+	case rest[0] == 'N':
+		return "NaN"
+	case rest[0] == 'I':
+		return "Infinity"
+	case len(rest) > 1 && rest[0] == '-' && rest[1] == 'I':
+		return "-Infinity"
+	}
+	return ""
+}
 
-	for {
-		p.skipWhiteSpace()
+// https://datatracker.ietf.org/doc/html/rfc8259#section-6
+// number = [ minus ] int [ frac ] [ exp ]
 
-		value, err := p.parseValue()
-		if err != nil {
-			return nil, err
-		}
+// parseNumber scans a number one part at a time:
+//
+//	[ '-' ] ( '0' | [1-9] [0-9]* ) [ '.' [0-9]+ ] [ ( 'e' | 'E' ) [ '+' | '-' ] [0-9]+ ]
+//
+// A 0 integer part ends the integer, so 0.5 and 0e3 are numbers but 01 and
+// 00 are not.
+func (p *Parser) parseNumber() (interface{}, error) {
+	start := p.pos
 
-		arr = append(arr, value)
+	// The integer is accumulated while scanning so the common case needs
+	// no second pass through strconv.
+	var mantissa uint64
+	overflow := false
 
-		p.skipWhiteSpace()
+	if p.input[p.pos] == '-' {
+		p.pos++
+	}
 
-		if p.input[p.pos] == EndArray {
+	switch {
+	case p.pos >= len(p.input):
+		return 0, &ParseError{msg: "expected digit after '-'", pos: p.pos, Kind: KindUnexpectedEOF}
+	case !isDigit(p.input[p.pos]):
+		return 0, &ParseError{msg: "expected digit after '-'", pos: p.pos}
+	case p.input[p.pos] == '0':
+		p.pos++
+		if p.AllowHexNumbers && p.pos < len(p.input) && (p.input[p.pos] == 'x' || p.input[p.pos] == 'X') {
+			return p.parseHexNumber(start)
+		}
+		if p.pos < len(p.input) && isDigit(p.input[p.pos]) {
+			return 0, &ParseError{msg: fmt.Sprintf("leading zero followed by digit %q", p.input[p.pos]), pos: p.pos}
+		}
+	default:
+		for p.pos < len(p.input) && isDigit(p.input[p.pos]) {
+			if mantissa > (math.MaxUint64-9)/10 {
+				overflow = true
+			}
+			mantissa = mantissa*10 + uint64(p.input[p.pos]-'0')
 			p.pos++
-			return arr, nil
 		}
+	}
 
-		if p.input[p.pos] != ValueSeparator {
-			return nil, &ParseError{msg: "Expected , in array value", pos: p.pos}
+	decimalFound := false
+	if p.pos < len(p.input) && p.input[p.pos] == '.' {
+		p.pos++
+		decimalFound = true
+		if err := p.parseDigits("digit after '.'"); err != nil {
+			return 0, err
 		}
+	}
 
+	exponentFound := false
+	if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
 		p.pos++
+		exponentFound = true
+		if p.pos < len(p.input) && (p.input[p.pos] == '+' || p.input[p.pos] == '-') {
+			p.pos++
+		}
+		if err := p.parseDigits("digit in exponent"); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := p.endNumber(); err != nil {
+		return 0, err
+	}
+
+	// Validation only checks the grammar, so a number like 1e400 that is
+	// too large to decode is still well-formed.
+	if p.validateOnly {
+		return nil, nil
+	}
+	val := p.input[start:p.pos]
+	if p.UseNumber || p.NumberMode == NumberUseNumber {
+		return Number(val), nil
+	}
+	if (decimalFound || exponentFound) && p.NumberMode == NumberBig {
+		f, err := parseBigFloat(val)
+		if err != nil {
+			return nil, &ParseError{msg: fmt.Sprintf("number %s out of range", val), pos: start}
+		}
+		return f, nil
+	}
+	if decimalFound || exponentFound || p.NumberMode == NumberAlwaysFloat {
+		return p.parseFloat(val, start)
 	}
 
+	if !overflow {
+		if val[0] == '-' && mantissa <= 1<<63 {
+			return intValue(-int64(mantissa)), nil
+		}
+		if val[0] != '-' && mantissa <= math.MaxInt64 {
+			return intValue(int64(mantissa)), nil
+		}
+	}
+	return p.parseInteger(val, start)
 }
 
-func (p *Parser) parseLiteral(literal string) (interface{}, error) {
-	start := p.pos
+// parseHexNumber parses the digits of an AllowHexNumbers integer starting
+// at start, with the current position on the 'x' of its 0x prefix.
+func (p *Parser) parseHexNumber(start int) (JSON, error) {
 	p.pos++
+	digits := p.pos
 
-	for {
-		current := p.input[p.pos]
-		switch current {
-		case ValueSeparator, EndArray, EndObject:
-			foundLiteral := p.input[start:p.pos]
+	var n uint64
+	overflow := false
+	for p.pos < len(p.input) && hexValue(p.input[p.pos]) >= 0 {
+		if n > math.MaxUint64>>4 {
+			overflow = true
+		}
+		n = n<<4 | uint64(hexValue(p.input[p.pos]))
+		p.pos++
+	}
 
-			if foundLiteral != literal {
-				return nil, &ParseError{msg: fmt.Sprintf("Expected %q, got %q", literal, foundLiteral), pos: p.pos}
-			}
+	if p.pos == digits {
+		return nil, p.expected("hex digit after '0x'")
+	}
+	if err := p.endNumber(); err != nil {
+		return nil, err
+	}
 
-			switch literal {
-			case "true":
-				return true, nil
-			case "false":
-				return false, nil
-			case "null":
-				return nil, nil
-			}
+	negative := p.input[start] == '-'
+	if overflow || (negative && n > 1<<63) || (!negative && n > math.MaxInt64) {
+		return nil, &ParseError{msg: fmt.Sprintf("number %s out of range", p.input[start:p.pos]), pos: start}
+	}
 
-			return nil, &ParseError{msg: fmt.Sprintf("Expected %q, got %q", literal, foundLiteral), pos: p.pos}
-		default:
-			p.pos++
+	i := int64(n)
+	if negative {
+		i = -i
+	}
+
+	switch {
+	case p.validateOnly:
+		return nil, nil
+	case p.UseNumber || p.NumberMode == NumberUseNumber:
+		return Number(strconv.FormatInt(i, 10)), nil
+	case p.NumberMode == NumberAlwaysFloat:
+		return float64(i), nil
+	}
+	return intValue(i), nil
+}
+
+// parseDigits consumes one or more digits, reporting that what was expected
+// if there are none.
+func (p *Parser) parseDigits(what string) error {
+	if p.pos >= len(p.input) || !isDigit(p.input[p.pos]) {
+		return p.expected(what)
+	}
+	for p.pos < len(p.input) && isDigit(p.input[p.pos]) {
+		p.pos++
+	}
+	return nil
+}
+
+// endNumber checks that a number is followed by a delimiter or the end of
+// the input.
+func (p *Parser) endNumber() error {
+	if p.pos >= len(p.input) {
+		return nil
+	}
+
+	switch c := p.input[p.pos]; c {
+	case ValueSeparator, EndArray, EndObject, ' ', '\n', '\t', '\r':
+		return nil
+	case '-':
+		return &ParseError{msg: "unexpected '-' in number", pos: p.pos}
+	case '/':
+		if p.AllowComments {
+			return nil
+		}
+	default:
+		if c < 0x20 {
+			return &ParseError{msg: "invalid control character in input", pos: p.pos}
+		}
+		if p.extraWhitespace() > 0 {
+			return nil
 		}
 	}
+	return &ParseError{msg: fmt.Sprintf("expected digit, got %s", quoteChar(p.input, p.pos)), pos: p.pos}
 }
 
-// https://datatracker.ietf.org/doc/html/rfc8259#section-6
-// number = [ minus ] int [ frac ] [ exp ]
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
 
-// 45 -> `-` (Negative number)
-// 46 -> `.` (Decimal Number)
-// 48-57 -> 0-9
-func (p *Parser) parseNumber() (interface{}, error) {
-	start := p.pos
-	decimalFound := false
+// parseFloat converts a number literal to float64, failing for magnitudes
+// beyond its range.
+func (p *Parser) parseFloat(val []byte, start int) (float64, error) {
+	f, err := strconv.ParseFloat(string(val), 64)
+	if err != nil {
+		return 0, &ParseError{msg: fmt.Sprintf("number %s out of range", val), pos: start}
+	}
+	return f, nil
+}
 
-	for {
-		switch p.input[p.pos] {
-		case 45, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57:
-			p.pos++
-		case 46:
-			if decimalFound {
-				return 0, &ParseError{msg: fmt.Sprintf("Expected digit, got %q", p.input[p.pos]), pos: p.pos}
-			}
-			p.pos++
-			decimalFound = true
-		case ValueSeparator, EndArray, EndObject:
-			val := p.input[start:p.pos]
-			if decimalFound {
-				return strconv.ParseFloat(strings.TrimSpace(val), 64)
-			}
-			return strconv.Atoi(val)
-		default:
-			return 0, &ParseError{msg: fmt.Sprintf("Expected digit, got %q", p.input[p.pos]), pos: p.pos}
+// parseInteger converts an integer literal to int, or to int64 where int is
+// too small. Integers beyond int64 become a float64, a *big.Int with
+// NumberBig, or a Number when LargeIntAsNumber is set.
+func (p *Parser) parseInteger(val []byte, start int) (JSON, error) {
+	n, err := strconv.ParseInt(string(val), 10, 64)
+	if err != nil {
+		if p.NumberMode == NumberBig {
+			i, _ := new(big.Int).SetString(string(val), 10)
+			return i, nil
+		}
+		if p.LargeIntAsNumber {
+			return Number(val), nil
 		}
+		return p.parseFloat(val, start)
 	}
+	return intValue(n), nil
 }
 
-func (p *Parser) skipWhiteSpace() {
+// intValue returns n as an int, or as an int64 where int is too small.
+func intValue(n int64) JSON {
+	if int64(int(n)) != n {
+		return n
+	}
+	return int(n)
+}
+
+func (p *Parser) skipWhiteSpace() error {
 	for p.pos < len(p.input) {
 		switch p.input[p.pos] {
 		case ' ', '\n', '\t', '\r':
 			p.pos++
+		case '/':
+			if !p.AllowComments {
+				return nil
+			}
+			if err := p.skipComment(); err != nil {
+				return err
+			}
 		default:
-			return
+			if p.input[p.pos] < 0x20 {
+				return &ParseError{msg: "invalid control character in input", pos: p.pos}
+			}
+			size := p.extraWhitespace()
+			if size == 0 {
+				return nil
+			}
+			p.pos += size
+		}
+	}
+	return nil
+}
+
+// defaultExtraWhitespace is what AllowExtraWhitespace skips when
+// ExtraWhitespace is not set.
+var defaultExtraWhitespace = []rune{'\u00A0', '\uFEFF'}
+
+// extraWhitespace returns the length of the AllowExtraWhitespace rune at the
+// current position, or 0 if there is none.
+func (p *Parser) extraWhitespace() int {
+	if !p.AllowExtraWhitespace || p.pos >= len(p.input) || p.input[p.pos] < utf8.RuneSelf {
+		return 0
+	}
+
+	set := p.ExtraWhitespace
+	if set == nil {
+		set = defaultExtraWhitespace
+	}
+
+	r, size := utf8.DecodeRune(p.input[p.pos:])
+	for _, ws := range set {
+		if r == ws {
+			return size
 		}
 	}
+	return 0
+}
+
+// skipComment consumes a // line comment or a /* block comment */.
+func (p *Parser) skipComment() error {
+	rest := p.input[p.pos:]
+
+	switch {
+	case bytes.HasPrefix(rest, []byte("//")):
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			p.pos = len(p.input)
+		} else {
+			p.pos += end + 1
+		}
+	case bytes.HasPrefix(rest, []byte("/*")):
+		end := bytes.Index(rest[2:], []byte("*/"))
+		if end < 0 {
+			return &ParseError{msg: "unterminated block comment", pos: p.pos, Kind: KindUnexpectedEOF}
+		}
+		p.pos += end + 4
+	default:
+		return &ParseError{msg: "expected '/' or '*' after '/'", pos: p.pos}
+	}
+
+	return nil
 }
 
 func main() {
@@ -0,0 +1,1857 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// expectParseError asserts that parsing input fails with a *ParseError at pos.
+func expectParseError(t *testing.T, input string, pos int) *ParseError {
+	t.Helper()
+
+	_, err := NewParser(input).Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.pos != pos {
+		t.Errorf("got error position %d, want %d", perr.pos, pos)
+	}
+
+	return perr
+}
+
+func TestParser(t *testing.T) {
+	input := `{
+		"name": "John Doe",
+		"age": 30,
+		"verified": false,
+		"friends": ["Jane", "James", "Jake"],
+		"address": {
+			"city": "New York",
+			"state": "NY"
+		}
+	}`
+
+	want := map[string]JSON{
+		"name":     "John Doe",
+		"age":      30,
+		"verified": false,
+		"friends":  []interface{}{"Jane", "James", "Jake"},
+		"address": map[string]JSON{
+			"city":  "New York",
+			"state": "NY",
+		},
+	}
+
+	got, err := NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseStringEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"quote", `"quote: \""`, `quote: "`},
+		{"backslash", `"a\\b"`, `a\b`},
+		{"solidus", `"a\/b"`, "a/b"},
+		{"escaped closing tag", `"<\/script>"`, "</script>"},
+		{"backspace", `"a\bb"`, "a\bb"},
+		{"form feed", `"a\fb"`, "a\fb"},
+		{"newline", `"line\none"`, "line\none"},
+		{"carriage return", `"a\rb"`, "a\rb"},
+		{"tab", `"a\tb"`, "a\tb"},
+		{"mixed", `"\"x\"\t\\\n/\/"`, "\"x\"\t\\\n//"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStringInvalidEscape(t *testing.T) {
+	_, err := NewParser(`"ab\xcd"`).Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.pos != 3 {
+		t.Errorf("got error position %d, want 3", perr.pos)
+	}
+}
+
+func TestParseStringUnicodeEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"latin", `"caf\u00e9"`, "café"},
+		{"uppercase hex", `"\u00C9"`, "É"},
+		{"cjk", `"\u4e2d\u6587"`, "中文"},
+		{"surrogate pair", `"\ud83d\ude00"`, "😀"},
+		{"mixed", `"ab\n\ud83d\ude00c"`, "ab\n😀c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStringInvalidUnicodeEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"invalid hex", `"ab\u12G4"`, 3},
+		{"lone high surrogate", `"\ud83dabc"`, 1},
+		{"lone low surrogate", `"x\ude00"`, 2},
+		{"high surrogate without low", `"\ud83d\u0041"`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser(tt.input).Parse()
+
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+
+			if perr.pos != tt.pos {
+				t.Errorf("got error position %d, want %d", perr.pos, tt.pos)
+			}
+		})
+	}
+}
+
+func TestParseStringMalformedHexEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"non-hex digits", `"\uGGGG"`, 1},
+		{"too short", `"\u12"`, 1},
+		{"too short before more input", `["\u12", 1]`, 2},
+		{"sign before digits", `"\u+123"`, 1},
+		{"end of input after u", `"\u`, 1},
+		{"end of input inside digits", `"ab\u00`, 3},
+		{"bad low surrogate digits", `"\ud83d\uZZZZ"`, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+			if want := `invalid \u escape: expected 4 hex digits`; perr.msg != want {
+				t.Errorf("got message %q, want %q", perr.msg, want)
+			}
+		})
+	}
+}
+
+func TestParseUnterminatedString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"object value", `{"key": "value`, 8},
+		{"object key", `{"key`, 1},
+		{"trailing backslash", `"abc\`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser(tt.input).Parse()
+
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+
+			if perr.pos != tt.pos {
+				t.Errorf("got error position %d, want %d", perr.pos, tt.pos)
+			}
+		})
+	}
+}
+
+func TestParseTopLevelScalars(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`42`, 42},
+		{`-7`, -7},
+		{`3.14`, 3.14},
+		{`true`, true},
+		{`false`, false},
+		{`null`, nil},
+		{`"hello"`, "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEmptyArray(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`[]`, []interface{}{}},
+		{`[ ]`, []interface{}{}},
+		{"[\n\t]", []interface{}{}},
+		{`[[]]`, []interface{}{[]interface{}{}}},
+		{`{"a":[]}`, map[string]JSON{"a": []interface{}{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrailingComma(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"array", `[1,2,]`, 5},
+		{"array with whitespace", `[1, 2, ]`, 7},
+		{"object", `{"a":1,}`, 7},
+		{"object with whitespace", `{"a":1, }`, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectParseError(t, tt.input, tt.pos)
+		})
+	}
+}
+
+func TestParseWithoutTrailingComma(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`[1,2]`, []interface{}{1, 2}},
+		{`{"a":1,"b":2}`, map[string]JSON{"a": 1, "b": 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumberExponent(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{`1e10`, 1e10},
+		{`1E10`, 1e10},
+		{`1e+2`, 100},
+		{`2.5E-3`, 2.5e-3},
+		{`6.022e23`, 6.022e23},
+		{`-1.5e2`, -150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumberInvalidExponent(t *testing.T) {
+	expectParseError(t, `[1e2e3]`, 4)
+	expectParseError(t, `[1e2.5]`, 4)
+}
+
+func TestParseNumberLeadingZero(t *testing.T) {
+	valid := []struct {
+		input string
+		want  JSON
+	}{
+		{`0`, 0},
+		{`-0`, 0},
+		{`0.5`, 0.5},
+		{`0e1`, 0.0},
+		{`10`, 10},
+	}
+
+	for _, tt := range valid {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []struct {
+		input string
+		pos   int
+	}{
+		{`01`, 1},
+		{`007`, 1},
+		{`-0123`, 2},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.input, func(t *testing.T) {
+			expectParseError(t, tt.input, tt.pos)
+		})
+	}
+}
+
+func TestParseErrorLineColumn(t *testing.T) {
+	input := "{\n\t\"a\": 1,\n\t\"b\": \"x\\q\"\n}"
+
+	perr := expectParseError(t, input, 19)
+
+	if perr.Line != 3 || perr.Column != 9 {
+		t.Errorf("got line %d, column %d, want line 3, column 9", perr.Line, perr.Column)
+	}
+
+	want := `Parse error at line 3, column 9 (position 19) in $.b: invalid escape character 'q'`
+	if perr.Error() != want {
+		t.Errorf("got %q, want %q", perr.Error(), want)
+	}
+}
+
+func TestParseErrorFirstLine(t *testing.T) {
+	perr := expectParseError(t, `[1,2,]`, 5)
+
+	if perr.Line != 1 || perr.Column != 6 {
+		t.Errorf("got line %d, column %d, want line 1, column 6", perr.Line, perr.Column)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`{"a":[1,2.5,"x"],"b":{"c":null}}`, map[string]JSON{
+			"a": []interface{}{1, 2.5, "x"},
+			"b": map[string]JSON{"c": nil},
+		}},
+		{`[true,false]`, []interface{}{true, false}},
+		{`"hi"`, "hi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Unmarshal([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	if _, err := Unmarshal([]byte(`[1,]`)); err == nil {
+		t.Error("expected error for trailing comma")
+	}
+}
+
+func TestNewParserFromReader(t *testing.T) {
+	input := `{"name": "John Doe", "friends": ["Jane", "James"], "address": {"city": "New York"}}`
+
+	want, err := NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := NewParserFromReader(strings.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestNewParserFromReaderError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader(`{"a":`), iotest.ErrReader(readErr))
+
+	if _, err := NewParserFromReader(r).Parse(); !errors.Is(err, readErr) {
+		t.Errorf("got %v, want %v", err, readErr)
+	}
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	p := NewParser(`[[{"a":[[[1]]]}]]`)
+	p.MaxDepth = 5
+
+	_, err := p.Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.pos != 9 || perr.msg != "maximum nesting depth exceeded" {
+		t.Errorf("got %v, want depth error at position 9", perr)
+	}
+}
+
+func TestParseMaxDepthAtLimit(t *testing.T) {
+	p := NewParser(`[[{"a":[[1]]}]]`)
+	p.MaxDepth = 5
+
+	if _, err := p.Parse(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseDuplicateKeys(t *testing.T) {
+	input := `{"a": 1, "b": 2, "a": 3}`
+
+	got, err := NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (map[string]JSON{"a": 3, "b": 2}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	p := NewParser(input)
+	p.DisallowDuplicateKeys = true
+
+	_, err = p.Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.pos != 17 || !strings.Contains(perr.Error(), `"a"`) {
+		t.Errorf("got %v, want duplicate key \"a\" at position 17", perr)
+	}
+}
+
+func TestParseDuplicateKeyStrategy(t *testing.T) {
+	input := `{"a":1,"a":2}`
+
+	tests := []struct {
+		name     string
+		strategy DuplicateKeyStrategy
+		want     JSON
+	}{
+		{"KeepLast", DuplicateKeepLast, map[string]JSON{"a": 2}},
+		{"KeepFirst", DuplicateKeepFirst, map[string]JSON{"a": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(input)
+			p.DuplicateKeys = tt.strategy
+			got, err := p.Parse()
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, %v; want %#v", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		p := NewParser(input)
+		p.DuplicateKeys = DuplicateError
+		_, err := p.Parse()
+		perr, ok := err.(*ParseError)
+		if !ok || perr.pos != 7 || perr.msg != `duplicate key "a"` {
+			t.Errorf("got %v, want duplicate key \"a\" at position 7", err)
+		}
+	})
+
+	t.Run("KeepFirstSkipsNestedValue", func(t *testing.T) {
+		p := NewParser(`{"a":1,"a":{"b":[2]},"c":3}`)
+		p.DuplicateKeys = DuplicateKeepFirst
+		p.PreserveOrder = true
+		got, err := p.Parse()
+		m, ok := got.(*OrderedMap)
+		if err != nil || !ok || !reflect.DeepEqual(m.Keys(), []string{"a", "c"}) {
+			t.Fatalf("got %#v, %v", got, err)
+		}
+		if v, _ := m.Get("a"); v != 1 {
+			t.Errorf("a = %#v, want 1", v)
+		}
+	})
+
+	t.Run("KeepFirstSpans", func(t *testing.T) {
+		p := NewParser(`{"a":1,"a":2}`)
+		p.DuplicateKeys = DuplicateKeepFirst
+		root, err := p.ParseWithSpans()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n := root.Members["a"]; n == nil || n.Span != (Span{Start: 5, End: 6}) {
+			t.Errorf("span of a = %+v, want 5-6", n)
+		}
+	})
+}
+
+func TestParseUnexpectedCharacter(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{`@`, 0},
+		{`}`, 0},
+		{`  ]`, 2},
+		{`{"a": @}`, 6},
+		{`{"a":`, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expectParseError(t, tt.input, tt.pos)
+		})
+	}
+}
+
+func TestParseUnexpectedNonASCIICharacter(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`[é]`, 1, "unexpected character 'é'"},
+		{"[\u00a0]", 1, `unexpected character '\u00a0'`},
+		{"[1, \xef\xbb\xbf]", 4, `unexpected character '\ufeff'`},
+		{"[\xff]", 1, "unexpected character 0xff"},
+		{"[1\xc2]", 2, "expected digit, got 0xc2"},
+		{`[true, "a" é]`, 11, "expected ',' or ']' but found 'é'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+			if perr.msg != tt.msg {
+				t.Errorf("got message %q, want %q", perr.msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseNumberFollowedByWhitespace(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`{ "a": 1 }`, map[string]JSON{"a": 1}},
+		{`[ 1 , 2 ]`, []interface{}{1, 2}},
+		{"[1.5\n,\t2e3\r\n]", []interface{}{1.5, 2000.0}},
+		{"1\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLiteralFollowedByWhitespace(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`true `, true},
+		{`[false ]`, []interface{}{false}},
+		{`null`, nil},
+		{`{"ok": true }`, map[string]JSON{"ok": true}},
+		{"[ null\n, true\t]", []interface{}{nil, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumberSign(t *testing.T) {
+	got, err := NewParser(`-42`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != -42 {
+		t.Errorf("got %#v, want -42", got)
+	}
+
+	invalid := []struct {
+		input string
+		pos   int
+	}{
+		{`-`, 1},
+		{`[-]`, 2},
+		{`--1`, 1},
+		{`1-2`, 1},
+		{`-.5`, 1},
+		{`+1`, 0},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.input, func(t *testing.T) {
+			expectParseError(t, tt.input, tt.pos)
+		})
+	}
+}
+
+func TestParseComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  JSON
+	}{
+		{"object", "{\n  // the name\n  \"name\": \"x\", /* inline */ \"n\": 1 // trailing\n}", map[string]JSON{"name": "x", "n": 1}},
+		{"array", "[1/* one */, true// yes\n, /**/null]", []interface{}{1, true, nil}},
+		{"leading and trailing", "/* a */ 42 // b", 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.AllowComments = true
+
+			got, err := p.Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+
+			if _, err := NewParser(tt.input).Parse(); err == nil {
+				t.Error("expected error with AllowComments off")
+			}
+		})
+	}
+}
+
+func TestParseUnterminatedComment(t *testing.T) {
+	p := NewParser(`[1, /* never closed ]`)
+	p.AllowComments = true
+
+	_, err := p.Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.pos != 4 || perr.msg != "unterminated block comment" {
+		t.Errorf("got %v, want unterminated block comment at position 4", perr)
+	}
+}
+
+func TestParseAllowTrailingCommas(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`[1,2,]`, []interface{}{1, 2}},
+		{"[1, 2 ,\n]", []interface{}{1, 2}},
+		{`{"a":1,}`, map[string]JSON{"a": 1}},
+		{`{"a":[true,], "b":{"c":null,},}`, map[string]JSON{"a": []interface{}{true}, "b": map[string]JSON{"c": nil}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.AllowTrailingCommas = true
+
+			got, err := p.Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+
+			if _, err := NewParser(tt.input).Parse(); err == nil {
+				t.Error("expected error in strict mode")
+			}
+		})
+	}
+}
+
+func TestParseAllowTrailingCommasStillRejectsEmpty(t *testing.T) {
+	for _, input := range []string{`[,]`, `{,}`, `[1,,]`} {
+		p := NewParser(input)
+		p.AllowTrailingCommas = true
+
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
+
+func TestParseExpectedTokenMessages(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"a":1 "b":2}`, `Parse error at line 1, column 8 (position 7): expected ',' or '}' but found '"'`},
+		{`{"a" 1}`, `Parse error at line 1, column 6 (position 5): expected ':' after object key but found '1'`},
+		{"[1\n 2]", `Parse error at line 2, column 2 (position 4): expected ',' or ']' but found '2'`},
+		{`["a":2]`, `Parse error at line 1, column 5 (position 4): expected ',' or ']' but found ':'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := NewParser(tt.input).Parse()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("got %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTruncatedContainers(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{`{"a":1`, "unexpected end of input, expected ',' or '}'"},
+		{`{"a":"x" `, "unexpected end of input, expected ',' or '}'"},
+		{`{"a":{"b":true}`, "unexpected end of input, expected ',' or '}'"},
+		{`[1`, "unexpected end of input, expected ',' or ']'"},
+		{`[1, [2] `, "unexpected end of input, expected ',' or ']'"},
+		{`[null`, "unexpected end of input, expected ',' or ']'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, len(tt.input))
+
+			if perr.msg != tt.msg {
+				t.Errorf("got %q, want %q", perr.msg, tt.msg)
+			}
+		})
+	}
+}
+
+// cancelAfterContext cancels itself after its Err method has been checked n
+// times, simulating a client that goes away mid-parse.
+type cancelAfterContext struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.n--
+	if c.n < 0 {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestParseContextCancelled(t *testing.T) {
+	input := "[" + strings.Repeat("1,", 100000) + "1]"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := NewParser(input).ParseContext(&cancelAfterContext{Context: ctx, cancel: cancel, n: 3})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	got, err := NewParser(`[1, 2, 3]`).ParseContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []interface{}{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseExplicitNull(t *testing.T) {
+	p := NewParser(`{"a": null, "b": [null, 1]}`)
+	p.ExplicitNull = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	obj := got.(map[string]JSON)
+	if obj["a"] != Null {
+		t.Errorf("got %#v for explicit null, want Null", obj["a"])
+	}
+
+	if v, ok := obj["missing"]; ok || v == Null {
+		t.Errorf("missing key decoded as %#v, want absent", v)
+	}
+
+	if want := []interface{}{Null, 1}; !reflect.DeepEqual(obj["b"], want) {
+		t.Errorf("got %#v, want %#v", obj["b"], want)
+	}
+
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := `{"a":null,"b":[null,1]}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestParseObjectKeyMustBeString(t *testing.T) {
+	invalid := []struct {
+		input string
+		pos   int
+	}{
+		{`{42: "x"}`, 1},
+		{`{name: "x"}`, 1},
+		{`{"a": 1, b: 2}`, 9},
+		{`{,}`, 1},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+
+			if perr.msg != "object key must be a string" {
+				t.Errorf("got %q, want %q", perr.msg, "object key must be a string")
+			}
+		})
+	}
+
+	got, err := NewParser(`{"42": "x"}`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (map[string]JSON{"42": "x"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	p := NewParser(`[1, @, 3, {"a" 1, "b": 2}, [true]]`)
+	value, errs := p.ParseAll()
+
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+
+	for i, want := range []int{4, 15} {
+		perr, ok := errs[i].(*ParseError)
+		if !ok {
+			t.Fatalf("error %d: expected *ParseError, got %T", i, errs[i])
+		}
+		if perr.pos != want {
+			t.Errorf("error %d: got position %d, want %d", i, perr.pos, want)
+		}
+	}
+
+	if len(errs) != 2 {
+		return
+	}
+
+	want := []interface{}{1, 3, map[string]JSON{"b": 2}, []interface{}{true}}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("got %#v, want %#v", value, want)
+	}
+}
+
+func TestParseAllNoErrors(t *testing.T) {
+	value, errs := NewParser(`{"a": [1, 2]}`).ParseAll()
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	want := map[string]JSON{"a": []interface{}{1, 2}}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("got %#v, want %#v", value, want)
+	}
+}
+
+func TestParseAllUnrecoverable(t *testing.T) {
+	_, errs := NewParser(`[1, @`).ParseAll()
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(errs), errs)
+	}
+}
+
+func TestParseControlCharacterBetweenTokens(t *testing.T) {
+	invalid := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"form feed", "[1,\f2]", 3},
+		{"NUL", "{\"a\":\x001}", 5},
+		{"vertical tab", "\v[]", 0},
+		{"form feed after number", "[1\f]", 2},
+		{"vertical tab after literal", "[true\v]", 5},
+		{"SOH after null", "[null\x01]", 5},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+
+			if perr.msg != "invalid control character in input" {
+				t.Errorf("got %q, want %q", perr.msg, "invalid control character in input")
+			}
+		})
+	}
+}
+
+func TestParseStringControlCharacters(t *testing.T) {
+	invalid := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"newline", "\"a\nb\"", 2},
+		{"tab", "[\"\tx\"]", 2},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+
+			if perr.msg != "unescaped control character in string" {
+				t.Errorf("got %q, want %q", perr.msg, "unescaped control character in string")
+			}
+		})
+	}
+
+	valid := []struct {
+		input string
+		want  JSON
+	}{
+		{`"a\nb"`, "a\nb"},
+		{`["\tx"]`, []interface{}{"\tx"}},
+	}
+
+	for _, tt := range valid {
+		got, err := NewParser(tt.input).Parse()
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error: %v", tt.input, err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseSkipsLeadingBOM(t *testing.T) {
+	got, err := NewParser("\xEF\xBB\xBF{\"a\": 1}").Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := (map[string]JSON{"a": 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	expectParseError(t, "[1, \xEF\xBB\xBF2]", 4)
+}
+
+func TestParseStringUTF8(t *testing.T) {
+	got, err := NewParser(`"caf` + "é 日本 \U0001F600" + `"`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "café 日本 \U0001F600"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	invalid := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{"lone continuation byte", "\"ab\x80\"", 3},
+		{"truncated sequence", "[\"\xE6\x97\"]", 2},
+		{"overlong encoding", "\"\xC0\xAF\"", 1},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+
+			if perr.msg != "invalid UTF-8 in string" {
+				t.Errorf("got %q, want %q", perr.msg, "invalid UTF-8 in string")
+			}
+		})
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	p := NewParser(`{"a": 1}`)
+	p.UseNumber = true
+
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`{"a": 1}`, map[string]JSON{"a": Number("1")}},
+		{`[true, "x"]`, []interface{}{true, "x"}},
+		{` 2.5 `, Number("2.5")},
+	}
+
+	for i, tt := range tests {
+		if i > 0 {
+			p.Reset(tt.input)
+		}
+
+		got, err := p.Parse()
+		if err != nil {
+			t.Fatalf("Parse(%q): unexpected error: %v", tt.input, err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+
+	p.Reset(`[`)
+	if _, err := p.Parse(); err == nil {
+		t.Fatal("expected error for truncated input")
+	}
+
+	p.Reset(`[1]`)
+	if got, err := p.Parse(); err != nil || !reflect.DeepEqual(got, []interface{}{Number("1")}) {
+		t.Errorf("after error: got %#v, %v", got, err)
+	}
+}
+
+func TestParseLiteralAtEndOfInput(t *testing.T) {
+	for _, input := range []string{`true`, `false`, `null`, " true\n"} {
+		if !Valid([]byte(input)) {
+			t.Errorf("Valid(%q) = false, want true", input)
+		}
+
+		tok := NewTokenizer(input)
+		token, err := tok.Next()
+		if err != nil {
+			t.Fatalf("Next() on %q: unexpected error: %v", input, err)
+		}
+		if next, err := tok.Next(); err != nil || next.Kind != TokenEOF {
+			t.Errorf("after %s: got %v, %v, want EOF", token.Kind, next.Kind, err)
+		}
+	}
+
+	for _, input := range []string{`t`, `tr`, `fals`, `nul`, `[nul`} {
+		if _, err := NewParser(input).Parse(); err == nil {
+			t.Errorf("Parse(%q): expected error for truncated literal", input)
+		}
+	}
+}
+
+func TestParseLiteralMismatch(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`[tru]`, 4, `invalid literal: expected 'e' in 'true'`},
+		{`nul`, 3, `invalid literal: expected 'l' in 'null'`},
+		{`[truee]`, 5, `invalid literal: unexpected 'e' after 'true'`},
+		{`{"a": nulll}`, 10, `invalid literal: unexpected 'l' after 'null'`},
+		{`treu`, 2, `invalid literal: expected 'u' in 'true'`},
+		{`[fasle]`, 3, `invalid literal: expected 'l' in 'false'`},
+		{`nUll`, 1, `invalid literal: expected 'u' in 'null'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+
+			if perr.msg != tt.msg {
+				t.Errorf("got %q, want %q", perr.msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseArrayElementErrorPosition(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+	}{
+		{`[1, @, 3]`, 4},
+		{`[[1, @]]`, 5},
+		{`{"a": [true, @]}`, 13},
+		{`[1, "ok", -]`, 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expectParseError(t, tt.input, tt.pos)
+		})
+	}
+
+	perr := expectParseError(t, `[1, @, 3]`, 4)
+	if perr.msg != `unexpected character '@'` {
+		t.Errorf("got %q, want %q", perr.msg, `unexpected character '@'`)
+	}
+}
+
+func TestParseAllowExtraWhitespace(t *testing.T) {
+	input := "{\u00a0\"a\":\u00a01,\ufeff\"b\": [true\u00a0]\u00a0}"
+
+	expectParseError(t, input, 1)
+
+	p := NewParser(input)
+	p.AllowExtraWhitespace = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]JSON{"a": 1, "b": []interface{}{true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	p = NewParser("[1,\u2003\u00a02]")
+	p.AllowExtraWhitespace = true
+	p.ExtraWhitespace = []rune{'\u2003'}
+
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected U+00A0 to be rejected when not in ExtraWhitespace")
+	}
+}
+
+func TestParseValueStream(t *testing.T) {
+	p := NewParser(`{"a":1}{"b":2} [3]` + "\n")
+
+	want := []struct {
+		value JSON
+		end   int
+	}{
+		{map[string]JSON{"a": 1}, 7},
+		{map[string]JSON{"b": 2}, 14},
+		{[]interface{}{3}, 18},
+	}
+
+	for _, w := range want {
+		value, end, err := p.ParseValue()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(value, w.value) || end != w.end {
+			t.Errorf("got %#v ending at %d, want %#v ending at %d", value, end, w.value, w.end)
+		}
+	}
+
+	if _, _, err := p.ParseValue(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+
+	if _, _, err := NewParser(`{"a":1}{"b"`).ParseValue(); err != nil {
+		t.Errorf("first value: unexpected error: %v", err)
+	}
+}
+
+func TestParseAllowInfNaN(t *testing.T) {
+	for _, input := range []string{`NaN`, `[Infinity]`, `{"a": -Infinity}`} {
+		if _, err := NewParser(input).Parse(); err == nil {
+			t.Errorf("Parse(%s): expected error by default", input)
+		}
+	}
+
+	p := NewParser(`[NaN, Infinity, -Infinity, -1]`)
+	p.AllowInfNaN = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	arr := got.([]interface{})
+	if f, ok := arr[0].(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("got %#v, want NaN", arr[0])
+	}
+	if arr[1] != math.Inf(1) || arr[2] != math.Inf(-1) || arr[3] != -1 {
+		t.Errorf("got %#v, want [NaN +Inf -Inf -1]", arr)
+	}
+
+	p = NewParser(`[Infinit]`)
+	p.AllowInfNaN = true
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected error for misspelled Infinity")
+	}
+}
+
+func TestParseObjectEndsAfterKey(t *testing.T) {
+	for _, input := range []string{`{"a"`, `{"a" `, `{"a": 1, "b"`} {
+		t.Run(input, func(t *testing.T) {
+			perr := expectParseError(t, input, len(input))
+
+			if want := "expected ':' after object key, got end of input"; perr.msg != want {
+				t.Errorf("got %q, want %q", perr.msg, want)
+			}
+		})
+	}
+
+	var v collectingVisitor
+	if err := NewParser(`{"a"`).Walk(&v); err == nil {
+		t.Error("Walk: expected error for truncated object")
+	}
+}
+
+func TestParseMaxInputBytes(t *testing.T) {
+	input := `{"a": [1, 2, 3]}`
+
+	p := NewParser(input)
+	p.MaxInputBytes = len(input)
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+
+	p = NewParser(input)
+	p.MaxInputBytes = len(input) - 1
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected error over the limit")
+	}
+
+	p = NewParserFromReader(strings.NewReader(input + strings.Repeat(" ", 1<<20)))
+	p.MaxInputBytes = 64
+	_, err := p.Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if perr.msg != "input exceeds 64 bytes" {
+		t.Errorf("got %q, want %q", perr.msg, "input exceeds 64 bytes")
+	}
+}
+
+func TestParseMaxValues(t *testing.T) {
+	input := `{"a": [1, 2, 3], "b": null}`
+
+	p := NewParser(input)
+	p.MaxValues = 6
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("unexpected error at the limit: %v", err)
+	}
+
+	p = NewParser(input)
+	p.MaxValues = 5
+	_, err := p.Parse()
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+	if perr.pos != 22 || perr.msg != "document has more than 5 values" {
+		t.Errorf("got %q at %d, want %q at 22", perr.msg, perr.pos, "document has more than 5 values")
+	}
+}
+
+func TestParseMismatchedBrackets(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`{]`, 1, `mismatched bracket: expected '}' but found ']'`},
+		{`[}`, 1, `mismatched bracket: expected ']' but found '}'`},
+		{`{"a":[}`, 6, `mismatched bracket: expected ']' but found '}'`},
+		{`[1, 2}`, 5, `mismatched bracket: expected ']' but found '}'`},
+		{`{"a": 1]`, 7, `mismatched bracket: expected '}' but found ']'`},
+		{`[1, }`, 4, `mismatched bracket: expected ']' but found '}'`},
+		{`{"a": 1, ]`, 9, `mismatched bracket: expected '}' but found ']'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+
+			if perr.msg != tt.msg {
+				t.Errorf("got %q, want %q", perr.msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseErrorPath(t *testing.T) {
+	tests := []struct {
+		input string
+		path  string
+	}{
+		{`{"address": {"city": tru}}`, `$.address.city`},
+		{`{"friends": ["a", "b", @]}`, `$.friends[2]`},
+		{`[{"a": 1}, {"b": [1 2]}]`, `$[1].b`},
+		{`{"a b": {"c": -}}`, `$["a b"].c`},
+		{`{"a": 1 "b": 2}`, `$`},
+		{`@`, `$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := NewParser(tt.input).Parse()
+
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+
+			if perr.Path != tt.path {
+				t.Errorf("got path %q, want %q", perr.Path, tt.path)
+			}
+		})
+	}
+
+	_, err := NewParser("{\n  \"address\": {\"city\": tru}\n}").Parse()
+	want := `Parse error at line 2, column 26 (position 27) in $.address.city: invalid literal: expected 'e' in 'true'`
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
+
+func TestParseReviver(t *testing.T) {
+	p := NewParser(`{"name": "jo", "secret": "pw", "tags": ["a", 1, "b"], "nested": {"secret": 1, "x": "y"}}`)
+
+	var keys []string
+	p.Reviver = func(key string, value JSON) JSON {
+		keys = append(keys, key)
+		if key == "secret" {
+			return Omit
+		}
+		if s, ok := value.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return value
+	}
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]JSON{
+		"name":   "JO",
+		"tags":   []interface{}{"A", 1, "B"},
+		"nested": map[string]JSON{"x": "Y"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	wantKeys := []string{"name", "secret", "0", "1", "2", "tags", "secret", "x", "nested", ""}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("got keys %q, want %q", keys, wantKeys)
+	}
+}
+
+// captureStdout returns what f writes to os.Stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestParseEmptyDocument(t *testing.T) {
+	for _, input := range []string{``, `   `, "\n\t\r\n"} {
+		t.Run(strconv.Quote(input), func(t *testing.T) {
+			var perr *ParseError
+			out := captureStdout(t, func() {
+				perr = expectParseError(t, input, len(input))
+			})
+
+			if perr.msg != "unexpected end of input: empty document" {
+				t.Errorf("got %q, want %q", perr.msg, "unexpected end of input: empty document")
+			}
+
+			if out != "" {
+				t.Errorf("Parse wrote %q to stdout", out)
+			}
+		})
+	}
+}
+
+func TestParseTrailingCharactersNoOutput(t *testing.T) {
+	var perr *ParseError
+	out := captureStdout(t, func() {
+		perr = expectParseError(t, `{"a": 1} garbage`, 9)
+	})
+
+	if perr.msg != "unexpected trailing characters" {
+		t.Errorf("got %q, want %q", perr.msg, "unexpected trailing characters")
+	}
+
+	if out != "" {
+		t.Errorf("Parse wrote %q to stdout", out)
+	}
+}
+
+func TestParseTrailingCharacters(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		want  JSON
+	}{
+		{`{"a":1} garbage`, 8, map[string]JSON{"a": 1}},
+		{`[1, 2]]`, 6, []interface{}{1, 2}},
+		{"\"x\"\n  y", 6, "x"},
+		{`true false`, 5, true},
+		{`42 43`, 3, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+
+			if perr.pos != tt.pos || perr.msg != "unexpected trailing characters" {
+				t.Errorf("got %q at %d, want %q at %d", perr.msg, perr.pos, "unexpected trailing characters", tt.pos)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got value %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInternKeys(t *testing.T) {
+	input := `[{"id": 1, "name": "a"}, {"id": 2, "name": "b", "é": 1}, {"é": 2, "id": 3}]`
+
+	want, err := NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := NewParser(input)
+	p.InternKeys = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	if len(p.keys) != 3 {
+		t.Errorf("got %d interned keys, want 3", len(p.keys))
+	}
+
+	p.Reset(`{"id": 1, "bad": "\x01"}`)
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected error for control character in value")
+	}
+
+	p.Reset(`{"i` + "\x01" + `d": 1}`)
+	if _, err := p.Parse(); err == nil {
+		t.Error("expected error for control character in key")
+	}
+
+	// A raw control character must not match a cached key decoded from
+	// its escape.
+	input = `[{"\u0001":1},{"` + "\x01" + `":2}]`
+	_, wantErr := NewParser(input).Parse()
+	p = NewParser(input)
+	p.InternKeys = true
+	if _, err := p.Parse(); err == nil || wantErr == nil || err.Error() != wantErr.Error() {
+		t.Errorf("got %v, want %v as without InternKeys", err, wantErr)
+	}
+}
+
+func TestParseAllowSingleQuotes(t *testing.T) {
+	for _, input := range []string{`{'a':'b'}`, `'x'`, `["a", 'b']`} {
+		if _, err := NewParser(input).Parse(); err == nil {
+			t.Errorf("Parse(%s): expected error by default", input)
+		}
+	}
+
+	p := NewParser(`{'a':'b', "c": 'it\'s "quoted"', 'd!': ['\n', "'"]}`)
+	p.AllowSingleQuotes = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]JSON{
+		"a":  "b",
+		"c":  `it's "quoted"`,
+		"d!": []interface{}{"\n", "'"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	for _, input := range []string{`'open`, `{'a' 1}`, `['a"]`} {
+		p := NewParser(input)
+		p.AllowSingleQuotes = true
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("Parse(%s): expected error", input)
+		}
+	}
+
+	p = NewParser(`{'k': 1, 'k': 2}`)
+	p.AllowSingleQuotes = true
+	p.InternKeys = true
+	if got, err := p.Parse(); err != nil || !reflect.DeepEqual(got, map[string]JSON{"k": 2}) {
+		t.Errorf("with InternKeys: got %#v, %v", got, err)
+	}
+}
+
+func TestParseAllowUnquotedKeys(t *testing.T) {
+	if _, err := NewParser(`{name: "x"}`).Parse(); err == nil {
+		t.Error("expected error for unquoted key by default")
+	}
+
+	p := NewParser(`{name: "x", _id: 1, $ref: true, a1_$: null, "quoted": [], Z:{b: 2}}`)
+	p.AllowUnquotedKeys = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]JSON{
+		"name":   "x",
+		"_id":    1,
+		"$ref":   true,
+		"a1_$":   nil,
+		"quoted": []interface{}{},
+		"Z":      map[string]JSON{"b": 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`{1a: 2}`, 1, "object key must be a string or identifier"},
+		{`{-a: 2}`, 1, "object key must be a string or identifier"},
+		{`{a-b: 2}`, 2, "expected ':' after object key but found '-'"},
+		{`{a b: 2}`, 3, "expected ':' after object key but found 'b'"},
+		{`{café: 2}`, 4, "expected ':' after object key but found 'é'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.AllowUnquotedKeys = true
+
+			_, err := p.Parse()
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			if perr.pos != tt.pos || perr.msg != tt.msg {
+				t.Errorf("got %q at %d, want %q at %d", perr.msg, perr.pos, tt.msg, tt.pos)
+			}
+		})
+	}
+}
+
+func TestParseObjectConsumesClosingBrace(t *testing.T) {
+	tests := []struct {
+		input string
+		want  map[string]JSON
+	}{
+		{`{"a":1}`, map[string]JSON{"a": 1}},
+		{`{"a":1,"b":2}`, map[string]JSON{"a": 1, "b": 2}},
+		{`{ "a" : 1 }`, map[string]JSON{"a": 1}},
+		{"{\n\t\"a\": 1,\n\t\"b\": {}\n}", map[string]JSON{"a": 1, "b": map[string]JSON{}}},
+		{`{ }`, map[string]JSON{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			// Each object must end exactly at its closing brace, leaving the
+			// next value in the stream untouched.
+			p := NewParser(tt.input + `7`)
+
+			got, end, err := p.ParseValue()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+			if end != len(tt.input) {
+				t.Errorf("got end %d, want %d", end, len(tt.input))
+			}
+
+			if next, _, err := p.ParseValue(); err != nil || next != 7 {
+				t.Errorf("got next value %#v, %v; want 7", next, err)
+			}
+		})
+	}
+
+	for _, input := range []string{`{"a":1 `, `{"a":1}}`, `{"a":1 ]`} {
+		if _, err := NewParser(input).Parse(); err == nil {
+			t.Errorf("Parse(%s): expected error", input)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("\ufeff{\"name\": \"app\", \"ports\": [80, 443]}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]JSON{"name": "app", "ports": []interface{}{80, 443}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{\n  \"a\": tru\n}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseFile(bad)
+	if perr, ok := err.(*ParseError); !ok || perr.Line != 2 {
+		t.Errorf("got %v, want *ParseError on line 2", err)
+	}
+
+	if _, err := ParseFile(filepath.Join(dir, "missing.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want fs.ErrNotExist", err)
+	}
+
+	_, err = ParseFile(dir)
+	if err == nil || !strings.HasPrefix(err.Error(), "reading "+dir+": ") {
+		t.Errorf("got %v, want read error wrapped with the path", err)
+	}
+}
+
+func TestParseEmptyArrayElement(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`[1,,2]`, 3, "unexpected ',' (empty array element)"},
+		{`[1, 2, , 3]`, 7, "unexpected ',' (empty array element)"},
+		{`[,1]`, 1, "unexpected ',' before first array element"},
+		{`[ ,]`, 2, "unexpected ',' before first array element"},
+		{`{"a": [[1],,]}`, 11, "unexpected ',' (empty array element)"},
+		{`[1,]`, 3, "unexpected trailing comma"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+			if perr.msg != tt.msg {
+				t.Errorf("got %q, want %q", perr.msg, tt.msg)
+			}
+
+			var v collectingVisitor
+			err := NewParser(tt.input).Walk(&v)
+			if werr, ok := err.(*ParseError); !ok || werr.msg != tt.msg {
+				t.Errorf("Walk: got %v, want %q", err, tt.msg)
+			}
+		})
+	}
+
+	got, err := NewParser(`[1,2]`).Parse()
+	if err != nil || !reflect.DeepEqual(got, []interface{}{1, 2}) {
+		t.Errorf("got %#v, %v; want [1 2]", got, err)
+	}
+
+	got, errs := NewParser(`[1,,2]`).ParseAll()
+	if len(errs) != 1 || !reflect.DeepEqual(got, []interface{}{1, 2}) {
+		t.Errorf("ParseAll: got %#v with errors %v", got, errs)
+	}
+}
+
+func TestParsePartial(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`{"a": 1, "b": [2, 3], "c": tru`, map[string]JSON{"a": 1, "b": []interface{}{2, 3}}},
+		{`{"a": {"x": 1, "y": `, map[string]JSON{"a": map[string]JSON{"x": 1}}},
+		{`[1, [2, {"k": true`, []interface{}{1, []interface{}{2, map[string]JSON{"k": true}}}},
+		{`{"a": 1 "b": 2}`, map[string]JSON{"a": 1}},
+		{`{`, map[string]JSON{}},
+		{`tru`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).ParsePartial()
+			if _, ok := err.(*ParseError); !ok {
+				t.Fatalf("got error %v, want a *ParseError", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	p := NewParser(`{"a": 1, "b": `)
+	p.PreserveOrder = true
+	got, err := p.ParsePartial()
+	if m, ok := got.(*OrderedMap); err == nil || !ok || !reflect.DeepEqual(m.Keys(), []string{"a"}) {
+		t.Errorf("PreserveOrder: got %#v, %v", got, err)
+	}
+
+	got, err = NewParser(`{"a": 1, "b": `).Parse()
+	if got != nil || err == nil {
+		t.Errorf("Parse: got %#v, %v; want nil and an error", got, err)
+	}
+
+	got, err = NewParser(`{"a": [1]}`).ParsePartial()
+	if err != nil || !reflect.DeepEqual(got, map[string]JSON{"a": []interface{}{1}}) {
+		t.Errorf("valid input: got %#v, %v", got, err)
+	}
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type MarshalError struct {
+	msg string
+}
+
+func (e *MarshalError) Error() string {
+	return fmt.Sprintf("Marshal error: %s", e.msg)
+}
+
+// JSONMarshaler is implemented by types that encode themselves. Marshal
+// checks that MarshalJSON returns well-formed JSON and writes it in place of
+// the value, reformatted only to match compact or indented output and to
+// apply the string escapes selected by MarshalOptions.
+type JSONMarshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// MarshalOptions controls how Marshal renders a JSON value.
+type MarshalOptions struct {
+	// ASCII escapes every non-ASCII character in strings as \uXXXX.
+	ASCII bool
+
+	// EscapeSlash writes / in strings as \/, so output embedded in an HTML
+	// <script> element cannot contain </script>.
+	EscapeSlash bool
+
+	// EscapeHTML writes <, > and & in strings as \u003c, \u003e and \u0026,
+	// and the line and paragraph separators U+2028 and U+2029 as \u2028
+	// and \u2029, so the output is safe to embed in HTML <script> elements.
+	EscapeHTML bool
+
+	// FloatFormat and FloatPrecision are passed to strconv.AppendFloat as
+	// its fmt and prec when writing a float64, so FloatFormat 'f' with
+	// FloatPrecision 2 writes two decimal places. FloatFormat must be one of
+	// 'e', 'E', 'f', 'g' or 'G'. When it is 0, floats are written in the
+	// shortest form that round-trips and FloatPrecision is ignored.
+	FloatFormat    byte
+	FloatPrecision int
+}
+
+// Marshal returns the compact JSON encoding of v. Object keys are emitted in
+// sorted order, except for *OrderedMap which keeps its own order.
+func Marshal(v JSON) ([]byte, error) {
+	return MarshalOptions{}.Marshal(v)
+}
+
+// MarshalHTMLSafe is like Marshal but escapes the characters that are
+// unsafe inside HTML, as described for MarshalOptions.EscapeHTML.
+func MarshalHTMLSafe(v JSON) ([]byte, error) {
+	return MarshalOptions{EscapeHTML: true}.Marshal(v)
+}
+
+// MarshalIndent is like Marshal but places each object member and array
+// element on its own line, starting with prefix followed by one copy of
+// indent per nesting level.
+func MarshalIndent(v JSON, prefix, indent string) ([]byte, error) {
+	return MarshalOptions{}.MarshalIndent(v, prefix, indent)
+}
+
+// AppendMarshal appends the compact JSON encoding of v to dst and returns
+// the extended buffer, like strconv.AppendInt. Reusing the buffer across
+// calls avoids allocating a new one for each value: once it is large
+// enough, writing a tree of objects, arrays, strings, booleans and int or
+// float64 numbers allocates nothing. On error dst is returned unchanged.
+func AppendMarshal(dst []byte, v JSON) ([]byte, error) {
+	return MarshalOptions{}.AppendMarshal(dst, v)
+}
+
+// AppendMarshal appends the compact JSON encoding of v to dst using the
+// options in o.
+func (o MarshalOptions) AppendMarshal(dst []byte, v JSON) ([]byte, error) {
+	e := newEncodeState()
+	defer e.free()
+	e.buf, e.opts = dst, o
+	if err := e.encode(v); err != nil {
+		return dst, err
+	}
+	return e.buf, nil
+}
+
+// Marshal returns the compact JSON encoding of v using the options in o.
+func (o MarshalOptions) Marshal(v JSON) ([]byte, error) {
+	e := newEncodeState()
+	defer e.free()
+	e.opts = o
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// MarshalIndent returns the indented JSON encoding of v using the options in o.
+func (o MarshalOptions) MarshalIndent(v JSON, prefix, indent string) ([]byte, error) {
+	e := newEncodeState()
+	defer e.free()
+	e.opts, e.pretty, e.prefix, e.indent = o, true, prefix, indent
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// MarshalCanonical returns the canonical encoding of v described by RFC 8785:
+// no insignificant whitespace, object keys sorted by their UTF-16 code units
+// and every number written in its shortest round-trip form. Structurally
+// equal values always produce identical bytes.
+func MarshalCanonical(v JSON) ([]byte, error) {
+	e := newEncodeState()
+	defer e.free()
+	e.canonical = true
+	if err := e.encode(v); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// Encode writes the compact JSON encoding of v to w while walking v, in
+// chunks of a few kilobytes, so the whole output is never held in memory at
+// once. It writes the same bytes as Marshal. On error, part of the output
+// may already have been written.
+func Encode(w io.Writer, v JSON) error {
+	e := newEncodeState()
+	defer e.free()
+	e.w = w
+	if err := e.encode(v); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+// spillSize is how many encoded bytes Encode collects before writing them.
+const spillSize = 4096
+
+type encodeState struct {
+	buf  []byte
+	opts MarshalOptions
+
+	// w receives buf piece by piece when streaming with Encode.
+	w io.Writer
+
+	// keys holds the sorted keys of the objects being written, innermost
+	// last, so sorting them needs no new slice per object.
+	keys []string
+
+	canonical bool
+
+	pretty bool
+	prefix string
+	indent string
+	depth  int
+}
+
+// encodeStatePool holds encodeStates between calls, so their scratch keys
+// slice is reused instead of being allocated for every value written.
+var encodeStatePool sync.Pool
+
+func newEncodeState() *encodeState {
+	if e, ok := encodeStatePool.Get().(*encodeState); ok {
+		return e
+	}
+	return &encodeState{}
+}
+
+// free returns e to encodeStatePool, keeping only its scratch keys.
+func (e *encodeState) free() {
+	keys := e.keys[:cap(e.keys)]
+	for i := range keys {
+		keys[i] = ""
+	}
+	*e = encodeState{keys: keys[:0]}
+	encodeStatePool.Put(e)
+}
+
+// spill hands the bytes encoded so far to w when streaming with Encode and
+// at least spillSize of them are waiting.
+func (e *encodeState) spill() error {
+	if e.w == nil || len(e.buf) < spillSize {
+		return nil
+	}
+	return e.flush()
+}
+
+// flush writes all bytes encoded so far to w.
+func (e *encodeState) flush() error {
+	_, err := e.w.Write(e.buf)
+	e.buf = e.buf[:0]
+	return err
+}
+
+// newline starts a new indented line when pretty printing.
+func (e *encodeState) newline() {
+	if !e.pretty {
+		return
+	}
+	e.buf = append(e.buf, '\n')
+	e.buf = append(e.buf, e.prefix...)
+	for i := 0; i < e.depth; i++ {
+		e.buf = append(e.buf, e.indent...)
+	}
+}
+
+func (e *encodeState) encode(v JSON) error {
+	switch val := v.(type) {
+	case nil, NullValue:
+		e.buf = append(e.buf, "null"...)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, val)
+	case string:
+		e.encodeString(val)
+	case int:
+		if e.canonical {
+			return e.encodeFloat(float64(val))
+		}
+		e.buf = strconv.AppendInt(e.buf, int64(val), 10)
+	case int64:
+		if e.canonical {
+			return e.encodeFloat(float64(val))
+		}
+		e.buf = strconv.AppendInt(e.buf, val, 10)
+	case float64:
+		return e.encodeFloat(val)
+	case *big.Int:
+		if val == nil {
+			e.buf = append(e.buf, "null"...)
+			break
+		}
+		if e.canonical {
+			f, _ := new(big.Float).SetInt(val).Float64()
+			return e.encodeFloat(f)
+		}
+		e.buf = val.Append(e.buf, 10)
+	case *big.Float:
+		if val == nil {
+			e.buf = append(e.buf, "null"...)
+			break
+		}
+		if val.IsInf() {
+			return &MarshalError{msg: fmt.Sprintf("unsupported float value %v", val)}
+		}
+		if e.canonical {
+			f, _ := val.Float64()
+			return e.encodeFloat(f)
+		}
+		e.buf = val.Append(e.buf, 'g', -1)
+	case RawMessage:
+		if val == nil {
+			e.buf = append(e.buf, "null"...)
+			break
+		}
+		if _, err := Compact(val); err != nil {
+			return &MarshalError{msg: fmt.Sprintf("invalid RawMessage: %v", err)}
+		}
+		e.appendRaw(val)
+	case RawValue:
+		// Options that change how scalars are written apply to the
+		// decoded value instead of the source text.
+		if e.canonical || e.opts != (MarshalOptions{}) {
+			return e.encode(val.Value)
+		}
+		e.buf = append(e.buf, val.Raw...)
+	case Number:
+		if e.canonical {
+			f, err := val.Float64()
+			if err != nil {
+				return &MarshalError{msg: fmt.Sprintf("invalid number %q", string(val))}
+			}
+			return e.encodeFloat(f)
+		}
+		if !val.valid() {
+			return &MarshalError{msg: fmt.Sprintf("invalid number %q", string(val))}
+		}
+		e.buf = append(e.buf, val...)
+	case map[string]JSON:
+		base := len(e.keys)
+		for key := range val {
+			e.keys = append(e.keys, key)
+		}
+		sort.Strings(e.keys[base:])
+		return e.encodeObject(base, func(key string) JSON { return val[key] })
+	case map[string]interface{}:
+		base := len(e.keys)
+		for key := range val {
+			e.keys = append(e.keys, key)
+		}
+		sort.Strings(e.keys[base:])
+		return e.encodeObject(base, func(key string) JSON { return val[key] })
+	case *OrderedMap:
+		base := len(e.keys)
+		for _, pair := range val.Pairs() {
+			e.keys = append(e.keys, pair.Key)
+		}
+		return e.encodeObject(base, func(key string) JSON {
+			value, _ := val.Get(key)
+			return value
+		})
+	case []interface{}:
+		return e.encodeArray(val)
+	case JSONMarshaler:
+		return e.encodeMarshaler(val)
+	default:
+		return &MarshalError{msg: fmt.Sprintf("unsupported type %T", v)}
+	}
+
+	return nil
+}
+
+// encodeMarshaler writes the output of m.MarshalJSON.
+func (e *encodeState) encodeMarshaler(m JSONMarshaler) error {
+	raw, err := m.MarshalJSON()
+	if err != nil {
+		return &MarshalError{msg: fmt.Sprintf("calling MarshalJSON for type %T: %v", m, err)}
+	}
+
+	if e.canonical {
+		v, err := NewParserBytes(raw).Parse()
+		if err != nil {
+			return &MarshalError{msg: fmt.Sprintf("invalid JSON from MarshalJSON for type %T: %v", m, err)}
+		}
+		return e.encode(v)
+	}
+
+	if e.pretty {
+		raw, err = Indent(raw, e.prefix+strings.Repeat(e.indent, e.depth), e.indent)
+	} else {
+		raw, err = Compact(raw)
+	}
+	if err != nil {
+		return &MarshalError{msg: fmt.Sprintf("invalid JSON from MarshalJSON for type %T: %v", m, err)}
+	}
+
+	e.appendRaw(raw)
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeObject writes an object whose keys are e.keys[base:], in that
+// order, looking up each value with get. The keys are popped off e.keys
+// when it returns, so nested objects share the one scratch slice.
+func (e *encodeState) encodeObject(base int, get func(string) JSON) error {
+	defer func() { e.keys = e.keys[:base] }()
+
+	n := len(e.keys) - base
+	if e.canonical {
+		keys := e.keys[base:]
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+	}
+
+	if n == 0 {
+		e.buf = append(e.buf, BeginObject, EndObject)
+		return nil
+	}
+
+	e.buf = append(e.buf, BeginObject)
+	e.depth++
+	for i := 0; i < n; i++ {
+		// Nested objects may grow e.keys, so index it afresh each time.
+		key := e.keys[base+i]
+		if i > 0 {
+			e.buf = append(e.buf, ValueSeparator)
+		}
+		e.newline()
+		e.encodeString(key)
+		e.buf = append(e.buf, NameSeparator)
+		if e.pretty {
+			e.buf = append(e.buf, ' ')
+		}
+		if err := e.encode(get(key)); err != nil {
+			return err
+		}
+		if err := e.spill(); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	e.newline()
+	e.buf = append(e.buf, EndObject)
+
+	return nil
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 requires.
+// This differs from byte order only for characters above U+FFFF, whose
+// surrogates sort before U+E000 through U+FFFF.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+func (e *encodeState) encodeArray(arr []interface{}) error {
+	if len(arr) == 0 {
+		e.buf = append(e.buf, BeginArray, EndArray)
+		return nil
+	}
+
+	e.buf = append(e.buf, BeginArray)
+	e.depth++
+	for i, elem := range arr {
+		if i > 0 {
+			e.buf = append(e.buf, ValueSeparator)
+		}
+		e.newline()
+		if err := e.encode(elem); err != nil {
+			return err
+		}
+		if err := e.spill(); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	e.newline()
+	e.buf = append(e.buf, EndArray)
+
+	return nil
+}
+
+func (e *encodeState) encodeFloat(f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return &MarshalError{msg: fmt.Sprintf("unsupported float value %v", f)}
+	}
+	if e.canonical {
+		e.buf = appendCanonicalFloat(e.buf, f)
+		return nil
+	}
+
+	switch e.opts.FloatFormat {
+	case 0:
+		e.buf = strconv.AppendFloat(e.buf, f, 'g', -1, 64)
+	case 'e', 'E', 'f', 'g', 'G':
+		e.buf = strconv.AppendFloat(e.buf, f, e.opts.FloatFormat, e.opts.FloatPrecision, 64)
+	default:
+		return &MarshalError{msg: fmt.Sprintf("unsupported float format %q", e.opts.FloatFormat)}
+	}
+	return nil
+}
+
+// appendCanonicalFloat formats f the way ECMAScript's Number.prototype.toString
+// does: plain decimal notation for magnitudes in [1e-6, 1e21), exponent
+// notation without padded exponent digits otherwise, and 0 for negative zero.
+func appendCanonicalFloat(buf []byte, f float64) []byte {
+	abs := math.Abs(f)
+	if abs == 0 {
+		return append(buf, '0')
+	}
+
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.AppendFloat(buf, f, 'f', -1, 64)
+	}
+
+	buf = strconv.AppendFloat(buf, f, 'e', -1, 64)
+
+	// Go pads the exponent to two digits: turn 1e-07 into 1e-7.
+	if n := len(buf); buf[n-2] == '0' && buf[n-4] == 'e' {
+		buf[n-2] = buf[n-1]
+		buf = buf[:n-1]
+	}
+	return buf
+}
+
+const hexDigits = "0123456789abcdef"
+
+// encodeString writes s as a quoted JSON string, reversing the escapes
+// handled by parseString.
+func (e *encodeState) encodeString(s string) {
+	e.buf = append(e.buf, '"')
+	e.appendEscaped(s)
+	e.buf = append(e.buf, '"')
+}
+
+// appendEscaped writes the characters of s with the escapes a JSON string
+// needs plus those selected by the options.
+func (e *encodeState) appendEscaped(s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				e.buf = append(e.buf, '\\', c)
+			case c == '/' && e.opts.EscapeSlash:
+				e.buf = append(e.buf, '\\', '/')
+			case (c == '<' || c == '>' || c == '&') && e.opts.EscapeHTML:
+				e.appendUnicodeEscape(rune(c))
+			case c == '\b':
+				e.buf = append(e.buf, '\\', 'b')
+			case c == '\f':
+				e.buf = append(e.buf, '\\', 'f')
+			case c == '\n':
+				e.buf = append(e.buf, '\\', 'n')
+			case c == '\r':
+				e.buf = append(e.buf, '\\', 'r')
+			case c == '\t':
+				e.buf = append(e.buf, '\\', 't')
+			case c < 0x20:
+				e.appendUnicodeEscape(rune(c))
+			default:
+				e.buf = append(e.buf, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			e.buf = append(e.buf, `\ufffd`...)
+		case (r == '\u2028' || r == '\u2029') && e.opts.EscapeHTML:
+			e.appendUnicodeEscape(r)
+		case e.opts.ASCII && r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			e.appendUnicodeEscape(r1)
+			e.appendUnicodeEscape(r2)
+		case e.opts.ASCII:
+			e.appendUnicodeEscape(r)
+		default:
+			e.buf = append(e.buf, s[i:i+size]...)
+		}
+		i += size
+	}
+}
+
+// appendRaw writes well-formed JSON text, applying the ASCII, EscapeSlash
+// and EscapeHTML options to the contents of its strings. Escape sequences
+// already in the text are kept as they are.
+func (e *encodeState) appendRaw(raw []byte) {
+	if !e.opts.ASCII && !e.opts.EscapeSlash && !e.opts.EscapeHTML {
+		e.buf = append(e.buf, raw...)
+		return
+	}
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '"' {
+			e.buf = append(e.buf, raw[i])
+			continue
+		}
+
+		end := stringEnd(raw, i)
+		e.buf = append(e.buf, '"')
+		start := i + 1
+		for j := start; j < end-1; j++ {
+			if raw[j] == '\\' {
+				e.appendEscaped(string(raw[start:j]))
+				e.buf = append(e.buf, raw[j], raw[j+1])
+				j++
+				start = j + 1
+			}
+		}
+		e.appendEscaped(string(raw[start : end-1]))
+		e.buf = append(e.buf, '"')
+		i = end - 1
+	}
+}
+
+func (e *encodeState) appendUnicodeEscape(r rune) {
+	e.buf = append(e.buf, '\\', 'u', hexDigits[r>>12&0xF], hexDigits[r>>8&0xF], hexDigits[r>>4&0xF], hexDigits[r&0xF])
+}
@@ -0,0 +1,578 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		in   JSON
+		want string
+	}{
+		{"null", nil, `null`},
+		{"true", true, `true`},
+		{"false", false, `false`},
+		{"int", -42, `-42`},
+		{"float", 2.5, `2.5`},
+		{"number", Number("10000000000000000000"), `10000000000000000000`},
+		{"string", "hello", `"hello"`},
+		{"empty array", []interface{}{}, `[]`},
+		{"array", []interface{}{1, "a", nil}, `[1,"a",null]`},
+		{"empty object", map[string]JSON{}, `{}`},
+		{"object", map[string]JSON{"b": 1, "a": []interface{}{true}}, `{"a":[true],"b":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalStringEscapes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`quote: "`, `"quote: \""`},
+		{`a\b`, `"a\\b"`},
+		{"line\none\ttab\r\b\f", `"line\none\ttab\r\b\f"`},
+		{"\x00\x1f", `"\u0000\u001f"`},
+		{"café", `"café"`},
+		{"bad \xff byte", `"bad \ufffd byte"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalASCII(t *testing.T) {
+	got, err := MarshalOptions{ASCII: true}.Marshal("café 😀")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `"caf\u00e9 \ud83d\ude00"`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	if _, err := Marshal(struct{}{}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestMarshalInvalidNumber(t *testing.T) {
+	for _, n := range []Number{"abc", "", "01", "1.", "+1", "0x10", "NaN", "1 "} {
+		_, err := Marshal(n)
+		if _, ok := err.(*MarshalError); !ok {
+			t.Errorf("Marshal(Number(%q)): got %v, want *MarshalError", n, err)
+		}
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	input := `{"address":{"city":"New York","state":"NY"},"age":30,"friends":["Jane","James"],"name":"John \"JD\" Doe","score":9.5,"verified":false}`
+
+	v, err := Unmarshal([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(got) != input {
+		t.Errorf("got %s, want %s", got, input)
+	}
+
+	again, err := Unmarshal(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(again, v) {
+		t.Errorf("got %#v, want %#v", again, v)
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	v, err := Unmarshal([]byte(`{"name":"John","tags":[],"meta":{},"friends":["Jane",{"age":2,"ok":true}],"address":{"city":"NY"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{
+  "address": {
+    "city": "NY"
+  },
+  "friends": [
+    "Jane",
+    {
+      "age": 2,
+      "ok": true
+    }
+  ],
+  "meta": {},
+  "name": "John",
+  "tags": []
+}`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMarshalIndentPrefix(t *testing.T) {
+	got, err := MarshalIndent([]interface{}{1, 2}, "//", "\t")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "[\n//\t1,\n//\t2\n//]"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	a, err := NewParser(`{"b": [1, {"y": 2, "x": 1}], "a": "z"}`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p := NewParser(`{ "a" : "z", "b" : [ 1.0, { "x" : 1, "y" : 2e0 } ] }`)
+	p.PreserveOrder = true
+	b, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gotA, err := MarshalCanonical(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gotB, err := MarshalCanonical(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"a":"z","b":[1,{"x":1,"y":2}]}`
+	if string(gotA) != want || string(gotB) != want {
+		t.Errorf("got %s and %s, want %s", gotA, gotB, want)
+	}
+}
+
+func TestMarshalCanonicalKeyOrder(t *testing.T) {
+	in := map[string]JSON{"דּ": 1, "\U0001F600": 2, "€": 3, "a": 4}
+
+	got, err := MarshalCanonical(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "{\"a\":4,\"€\":3,\"\U0001F600\":2,\"דּ\":1}"
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMarshalCanonicalNumbers(t *testing.T) {
+	tests := []struct {
+		in   JSON
+		want string
+	}{
+		{0.0, `0`},
+		{math.Copysign(0, -1), `0`},
+		{1e21, `1e+21`},
+		{1e20, `100000000000000000000`},
+		{1e-7, `1e-7`},
+		{0.000001, `0.000001`},
+		{-1.5e-10, `-1.5e-10`},
+		{Number("1.50"), `1.5`},
+		{Number("1E3"), `1000`},
+		{Number("-0"), `0`},
+		{42, `42`},
+		{9007199254740993, `9007199254740992`},
+		{int64(-9007199254740993), `-9007199254740992`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := MarshalCanonical(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	in := map[string]JSON{
+		"name": "John Doe",
+		"tags": []interface{}{"a", 1, 2.5, nil, true},
+		"nested": map[string]JSON{
+			"empty": []interface{}{},
+			"deep":  []interface{}{map[string]JSON{"x": "é\n"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, in); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != string(want) {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+}
+
+func TestEncodeWriteError(t *testing.T) {
+	large := make([]interface{}, 10000)
+	for i := range large {
+		large[i] = "some string value"
+	}
+
+	if err := Encode(&failingWriter{}, large); err == nil {
+		t.Error("expected write error")
+	}
+}
+
+func TestEncodeStreams(t *testing.T) {
+	large := make([]interface{}, 10000)
+	for i := range large {
+		large[i] = map[string]JSON{"id": i, "name": "some string value"}
+	}
+
+	var w countingWriter
+	if err := Encode(&w, large); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := Marshal(large)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.String() != string(want) {
+		t.Error("Encode output differs from Marshal")
+	}
+
+	if w.writes < len(want)/(2*spillSize) {
+		t.Errorf("got %d writes for %d bytes, want the output in chunks", w.writes, len(want))
+	}
+	if w.largest > 2*spillSize {
+		t.Errorf("largest write was %d bytes, want about %d", w.largest, spillSize)
+	}
+
+	// A failed write stops the encoding instead of being reported at the end.
+	fw := &failingWriter{}
+	if err := Encode(fw, large); err == nil || fw.writes != 1 {
+		t.Errorf("got %v after %d writes, want an error after the first", err, fw.writes)
+	}
+}
+
+// countingWriter records how many writes it receives and the largest one.
+type countingWriter struct {
+	bytes.Buffer
+	writes  int
+	largest int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if len(p) > w.largest {
+		w.largest = len(p)
+	}
+	return w.Buffer.Write(p)
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("write failed")
+}
+
+// testMoney marshals as a quoted decimal string such as "12.50 EUR".
+type testMoney struct {
+	Cents    int64
+	Currency string
+}
+
+func (m testMoney) MarshalJSON() ([]byte, error) {
+	if m.Currency == "" {
+		return nil, errors.New("missing currency")
+	}
+	return []byte(fmt.Sprintf(`"%d.%02d %s"`, m.Cents/100, m.Cents%100, m.Currency)), nil
+}
+
+// testBadMarshaler returns text that is not JSON.
+type testBadMarshaler struct{}
+
+func (testBadMarshaler) MarshalJSON() ([]byte, error) { return []byte(`{oops`), nil }
+
+// testSpacedMarshaler returns JSON with insignificant whitespace.
+type testSpacedMarshaler struct{}
+
+func (testSpacedMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{ "b": [1, 2.0] }`), nil
+}
+
+func TestMarshalJSONMarshaler(t *testing.T) {
+	v := map[string]JSON{
+		"price": testMoney{Cents: 1250, Currency: "EUR"},
+		"raw":   testSpacedMarshaler{},
+	}
+
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"price":"12.50 EUR","raw":{"b":[1,2.0]}}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	got, err = MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "{\n  \"price\": \"12.50 EUR\",\n  \"raw\": {\n    \"b\": [\n      1,\n      2.0\n    ]\n  }\n}"
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	got, err = MarshalCanonical(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"price":"12.50 EUR","raw":{"b":[1,2]}}`; string(got) != want {
+		t.Errorf("canonical: got %s, want %s", got, want)
+	}
+
+	for _, bad := range []JSON{testBadMarshaler{}, testMoney{Cents: 1}} {
+		if _, err := Marshal([]interface{}{bad}); err == nil {
+			t.Errorf("Marshal(%#v): expected error", bad)
+		} else if _, ok := err.(*MarshalError); !ok {
+			t.Errorf("Marshal(%#v): got %T, want *MarshalError", bad, err)
+		}
+	}
+}
+
+func TestAppendMarshal(t *testing.T) {
+	values := []JSON{
+		map[string]JSON{"b": []interface{}{1, "x", nil}, "a": true},
+		"café",
+		2.5,
+		[]interface{}{},
+	}
+
+	buf := []byte("prefix:")
+	for _, v := range values {
+		want, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal: unexpected error: %v", err)
+		}
+
+		got, err := AppendMarshal(buf[:len("prefix:")], v)
+		if err != nil {
+			t.Fatalf("AppendMarshal: unexpected error: %v", err)
+		}
+		if string(got) != "prefix:"+string(want) {
+			t.Errorf("got %s, want prefix:%s", got, want)
+		}
+		buf = got
+	}
+
+	ordered := NewOrderedMap()
+	ordered.Set("z", map[string]JSON{"y": 1.5, "x": []interface{}{"s", false}})
+	ordered.Set("a", nil)
+	var tree JSON = []interface{}{ordered, map[string]interface{}{"k": map[string]JSON{"b": 1, "a": 2}}}
+
+	var reused []byte
+	allocs := testing.AllocsPerRun(100, func() {
+		var err error
+		if reused, err = AppendMarshal(reused[:0], tree); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations per call, want 0", allocs)
+	}
+	if want := `[{"z":{"x":["s",false],"y":1.5},"a":null},{"k":{"a":2,"b":1}}]`; string(reused) != want {
+		t.Errorf("got %s, want %s", reused, want)
+	}
+
+	dst := []byte("keep")
+	got, err := AppendMarshal(dst, []interface{}{1, math.NaN()})
+	if err == nil {
+		t.Fatal("expected error for NaN")
+	}
+	if string(got) != "keep" {
+		t.Errorf("got %q after error, want dst unchanged", got)
+	}
+}
+
+func TestMarshalFloatFormat(t *testing.T) {
+	v := []interface{}{1.0 / 3.0, 2.0, 1e21, 7}
+
+	tests := []struct {
+		name string
+		opts MarshalOptions
+		want string
+	}{
+		{"default", MarshalOptions{}, `[0.3333333333333333,2,1e+21,7]`},
+		{"fixed 3 places", MarshalOptions{FloatFormat: 'f', FloatPrecision: 3}, `[0.333,2.000,1000000000000000000000.000,7]`},
+		{"fixed 0 places", MarshalOptions{FloatFormat: 'f'}, `[0,2,1000000000000000000000,7]`},
+		{"exponent", MarshalOptions{FloatFormat: 'e', FloatPrecision: 2}, `[3.33e-01,2.00e+00,1.00e+21,7]`},
+		{"significant digits", MarshalOptions{FloatFormat: 'g', FloatPrecision: 4}, `[0.3333,2,1e+21,7]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.Marshal(v)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if !Valid(got) {
+				t.Errorf("output %s is not valid JSON", got)
+			}
+		})
+	}
+
+	if _, err := (MarshalOptions{FloatFormat: 'x'}).Marshal(1.5); err == nil {
+		t.Error("expected error for unsupported float format")
+	}
+}
+
+func TestMarshalEscapeSlash(t *testing.T) {
+	v := map[string]JSON{"html": "</script><a href=\"/x\">", "path": "a/b"}
+
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"html":"</script><a href=\"/x\">","path":"a/b"}`; string(got) != want {
+		t.Errorf("default: got %s, want %s", got, want)
+	}
+
+	got, err = MarshalOptions{EscapeSlash: true}.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"html":"<\/script><a href=\"\/x\">","path":"a\/b"}`; string(got) != want {
+		t.Errorf("EscapeSlash: got %s, want %s", got, want)
+	}
+
+	back, err := Unmarshal(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(back, v) {
+		t.Errorf("round trip gave %#v, want %#v", back, v)
+	}
+}
+
+func TestMarshalHTMLSafe(t *testing.T) {
+	v := []interface{}{"<b>Tom & Jerry</b>", "line\u2028sep\u2029end", map[string]JSON{"a<b": 1}}
+
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "[\"<b>Tom & Jerry</b>\",\"line\u2028sep\u2029end\",{\"a<b\":1}]"; string(got) != want {
+		t.Errorf("default: got %s, want %s", got, want)
+	}
+
+	got, err = MarshalHTMLSafe(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `["\u003cb\u003eTom \u0026 Jerry\u003c/b\u003e","line\u2028sep\u2029end",{"a\u003cb":1}]`
+	if string(got) != want {
+		t.Errorf("MarshalHTMLSafe: got %s, want %s", got, want)
+	}
+
+	back, err := Unmarshal(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(back, v) {
+		t.Errorf("round trip gave %#v, want %#v", back, v)
+	}
+}
+
+type testScriptMarshaler struct{}
+
+func (testScriptMarshaler) MarshalJSON() ([]byte, error) { return []byte(`"</script>"`), nil }
+
+func TestMarshalEscapesRawText(t *testing.T) {
+	v := []interface{}{testScriptMarshaler{}, RawMessage(`{"a\"/": "éé & </b>"}`)}
+
+	tests := []struct {
+		name string
+		opts MarshalOptions
+		want string
+	}{
+		{"default", MarshalOptions{}, `["</script>",{"a\"/": "éé & </b>"}]`},
+		{"EscapeHTML", MarshalOptions{EscapeHTML: true}, `["\u003c/script\u003e",{"a\"/": "éé \u0026 \u003c/b\u003e"}]`},
+		{"EscapeSlash", MarshalOptions{EscapeSlash: true}, `["<\/script>",{"a\"\/": "éé & <\/b>"}]`},
+		{"ASCII", MarshalOptions{ASCII: true}, `["</script>",{"a\"/": "\u00e9\u00e9 & </b>"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.Marshal(v)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
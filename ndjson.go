@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ParseNDJSON parses newline-delimited JSON, where each non-blank line holds
+// one complete JSON value. Parsing stops at the first malformed line.
+func ParseNDJSON(data []byte) ([]JSON, error) {
+	values := make([]JSON, 0)
+	offset := 0
+
+	for n, line := range bytes.Split(data, []byte{'\n'}) {
+		start := offset
+		offset += len(line) + 1
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		value, err := NewParserBytes(line).Parse()
+		if perr, ok := err.(*ParseError); ok {
+			perr.pos += start
+			perr.Line = n + 1
+			return nil, perr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
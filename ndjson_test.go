@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNDJSON(t *testing.T) {
+	input := "{\"id\": 1, \"level\": \"info\"}\n\n{\"id\": 2, \"tags\": [\"a\"]}\r\n\"done\"\n"
+
+	got, err := ParseNDJSON([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []JSON{
+		map[string]JSON{"id": 1, "level": "info"},
+		map[string]JSON{"id": 2, "tags": []interface{}{"a"}},
+		"done",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseNDJSONMalformedLine(t *testing.T) {
+	input := "{\"id\": 1}\n\n{\"id\": [1,]}\n{\"id\": 3}\n"
+
+	_, err := ParseNDJSON([]byte(input))
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.Line != 3 || perr.Column != 11 || perr.pos != 21 {
+		t.Errorf("got line %d, column %d, position %d, want line 3, column 11, position 21", perr.Line, perr.Column, perr.pos)
+	}
+}
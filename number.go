@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// NumberMode selects how Parser decodes JSON numbers.
+type NumberMode int
+
+const (
+	// NumberIntAndFloat decodes integers as int and anything with a
+	// fraction or exponent as float64. It is the default.
+	NumberIntAndFloat NumberMode = iota
+
+	// NumberAlwaysFloat decodes every number as float64, like encoding/json.
+	NumberAlwaysFloat
+
+	// NumberUseNumber decodes every number as a Number holding its literal
+	// text. Marshal writes a Number back verbatim, so 1.0 and 1e2 survive a
+	// round trip instead of becoming 1 and 100.
+	NumberUseNumber
+
+	// NumberBig decodes numbers with a fraction or exponent as *big.Float,
+	// with enough precision to keep every digit of the literal, and
+	// integers beyond int64 as *big.Int. Other integers decode as int.
+	// Marshal writes both back without losing precision. Floats beyond
+	// about 10^±1000 are rejected as out of range.
+	NumberBig
+)
+
+// Number is a JSON number literal kept in its original textual form, so
+// integers beyond the range of int64 survive decoding unchanged.
+type Number string
+
+// String returns the literal text of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// valid reports whether n is a number literal as defined by the JSON grammar.
+func (n Number) valid() bool {
+	if n == "" || (n[0] != '-' && !isDigit(n[0])) {
+		return false
+	}
+	p := NewParser(string(n))
+	p.validateOnly = true
+	_, err := p.parseNumber()
+	return err == nil && p.pos == len(p.input)
+}
+
+// maxBigFloatExp bounds the binary exponent of a NumberBig float to about
+// 10^±1000. Writing a *big.Float back as decimal takes time that grows
+// faster than its exponent, so a short literal such as 1e10000000 would
+// otherwise take minutes to marshal.
+const maxBigFloatExp = 3322
+
+// parseBigFloat converts a number literal to a *big.Float holding at least
+// four mantissa bits per character, more than any decimal digit needs, so
+// the shortest decimal that Marshal writes back has the literal's value.
+func parseBigFloat(val []byte) (*big.Float, error) {
+	prec := uint(len(val)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(string(val), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, err
+	}
+	if exp := f.MantExp(nil); exp > maxBigFloatExp || exp < -maxBigFloatExp {
+		return nil, &strconv.NumError{Func: "ParseFloat", Num: string(val), Err: strconv.ErrRange}
+	}
+	return f, nil
+}
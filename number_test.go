@@ -0,0 +1,434 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUseNumber(t *testing.T) {
+	p := NewParser(`[10000000000000000000, -42, 2.5e3]`)
+	p.UseNumber = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []interface{}{Number("10000000000000000000"), Number("-42"), Number("2.5e3")}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+
+	arr := got.([]interface{})
+	if s := arr[0].(Number).String(); s != "10000000000000000000" {
+		t.Errorf("String() = %q, want %q", s, "10000000000000000000")
+	}
+
+	if i, err := arr[1].(Number).Int64(); err != nil || i != -42 {
+		t.Errorf("Int64() = %d, %v, want -42", i, err)
+	}
+
+	if f, err := arr[2].(Number).Float64(); err != nil || f != 2500 {
+		t.Errorf("Float64() = %v, %v, want 2500", f, err)
+	}
+
+	if _, err := arr[0].(Number).Int64(); err == nil {
+		t.Errorf("Int64() on %s should overflow", arr[0])
+	}
+}
+
+func TestUseNumberDefault(t *testing.T) {
+	got, err := NewParser(`42`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != 42 {
+		t.Errorf("got %#v, want 42", got)
+	}
+}
+
+func TestNumberMode(t *testing.T) {
+	tests := []struct {
+		mode NumberMode
+		want []interface{}
+	}{
+		{NumberIntAndFloat, []interface{}{5, 5.0}},
+		{NumberAlwaysFloat, []interface{}{5.0, 5.0}},
+		{NumberUseNumber, []interface{}{Number("5"), Number("5.0")}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(`[5, 5.0]`)
+		p.NumberMode = tt.mode
+
+		got, err := p.Parse()
+		if err != nil {
+			t.Fatalf("mode %d: unexpected error: %v", tt.mode, err)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mode %d: got %#v, want %#v", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParseLargeIntegers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  JSON
+	}{
+		{`9223372036854775807`, intOrInt64(math.MaxInt64)},
+		{`-9223372036854775808`, intOrInt64(math.MinInt64)},
+		{`9223372036854775808`, 9223372036854775808.0},
+		{`-100000000000000000000`, -1e20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	p := NewParser(`[9223372036854775807, 9223372036854775808, -100000000000000000000]`)
+	p.LargeIntAsNumber = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []interface{}{intOrInt64(math.MaxInt64), Number("9223372036854775808"), Number("-100000000000000000000")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+// intOrInt64 returns n as the type parseInteger picks on this platform.
+func intOrInt64(n int64) JSON {
+	if int64(int(n)) != n {
+		return n
+	}
+	return int(n)
+}
+
+func TestParseIntegerMatchesStrconv(t *testing.T) {
+	inputs := []string{
+		"0", "-0", "7", "-7", "10", "-10", "255", "256", "65535", "2147483647", "-2147483648",
+		"4294967296", "9223372036854775806", "9223372036854775807", "-9223372036854775807",
+		"-9223372036854775808", "9223372036854775808", "-9223372036854775809",
+		"18446744073709551615", "18446744073709551616", "123456789012345678901234567890",
+	}
+
+	for _, input := range inputs {
+		got, err := NewParser(input).Parse()
+		if err != nil {
+			t.Fatalf("Parse(%s): unexpected error: %v", input, err)
+		}
+
+		var want JSON
+		if n, err := strconv.ParseInt(input, 10, 64); err == nil {
+			want = intOrInt64(n)
+		} else {
+			want, _ = strconv.ParseFloat(input, 64)
+		}
+
+		if got != want {
+			t.Errorf("Parse(%s) = %#v, want %#v", input, got, want)
+		}
+	}
+}
+
+func TestNumberLiteralRoundTrip(t *testing.T) {
+	for _, input := range []string{`1.0`, `1e2`, `1`, `-0.50`, `[1.0,1E+2,10]`, `{"a":2.50}`} {
+		p := NewParser(input)
+		p.NumberMode = NumberUseNumber
+
+		v, err := p.Parse()
+		if err != nil {
+			t.Fatalf("Parse(%s): unexpected error: %v", input, err)
+		}
+
+		got, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal: unexpected error: %v", err)
+		}
+
+		if string(got) != input {
+			t.Errorf("round trip of %s gave %s", input, got)
+		}
+	}
+
+	if !Equal(Number("1.0"), 1) {
+		t.Error("Number 1.0 should equal int 1")
+	}
+}
+
+func TestNumberBig(t *testing.T) {
+	const (
+		integer = `1234567890123456789012345678901234567890`
+		decimal = `3.1415926535897932384626433832795028841971693993751`
+	)
+
+	p := NewParser(`[0.1, ` + integer + `, ` + decimal + `, 42, -2.5e-30]`)
+	p.NumberMode = NumberBig
+
+	v, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	arr := v.([]interface{})
+
+	if f, ok := arr[0].(*big.Float); !ok || f.Text('g', -1) != "0.1" {
+		t.Errorf("got %#v, want *big.Float 0.1", arr[0])
+	}
+
+	want, _ := new(big.Int).SetString(integer, 10)
+	if i, ok := arr[1].(*big.Int); !ok || i.Cmp(want) != 0 {
+		t.Errorf("got %#v, want *big.Int %s", arr[1], integer)
+	}
+
+	if f, ok := arr[2].(*big.Float); !ok || f.Text('g', -1) != decimal {
+		t.Errorf("got %v, want *big.Float %s", arr[2], decimal)
+	}
+
+	if arr[3] != 42 {
+		t.Errorf("got %#v, want int 42", arr[3])
+	}
+
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: unexpected error: %v", err)
+	}
+	if want := `[0.1,` + integer + `,` + decimal + `,42,-2.5e-30]`; string(got) != want {
+		t.Errorf("round trip gave %s, want %s", got, want)
+	}
+
+	if !Equal(arr[0], 0.1) || !Equal(arr[3], 42) {
+		t.Error("big values should compare equal to their float64 counterparts")
+	}
+
+	var dst struct {
+		N int     `json:"n"`
+		F float64 `json:"f"`
+	}
+	if err := Populate(map[string]JSON{"n": big.NewFloat(3), "f": arr[0]}, &dst); err != nil || dst.N != 3 || dst.F != 0.1 {
+		t.Errorf("Populate: got %+v, %v", dst, err)
+	}
+}
+
+func TestNumberBigExponentLimit(t *testing.T) {
+	tests := []string{
+		`1e10000000`,
+		`1e1002`,
+		`-1.5e-1002`,
+		`0.` + strings.Repeat("0", 1100) + `1`,
+	}
+
+	for _, input := range tests {
+		name := input
+		if len(name) > 20 {
+			name = name[:20]
+		}
+		t.Run(name, func(t *testing.T) {
+			p := NewParser(input)
+			p.NumberMode = NumberBig
+			_, err := p.Parse()
+			perr, ok := err.(*ParseError)
+			if !ok || perr.msg != "number "+input+" out of range" {
+				t.Errorf("got %v, want number out of range", err)
+			}
+		})
+	}
+
+	// Values just inside the limit still decode, and writing them back
+	// stays fast.
+	p := NewParser(`[1e1000, -1.5e-1000]`)
+	p.NumberMode = NumberBig
+	v, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	got, err := Marshal(v)
+	if err != nil || string(got) != `[1e+1000,-1.5e-1000]` {
+		t.Errorf("got %s, %v", got, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Marshal took %v", d)
+	}
+}
+
+func TestParseNumberGrammar(t *testing.T) {
+	valid := []struct {
+		input string
+		want  JSON
+	}{
+		{`0`, 0},
+		{`-0`, 0},
+		{`0.5`, 0.5},
+		{`-0.5`, -0.5},
+		{`0e3`, 0.0},
+		{`0E3`, 0.0},
+		{`0e+3`, 0.0},
+		{`0e-3`, 0.0},
+		{`0.0`, 0.0},
+		{`0.05e1`, 0.5},
+		{`10`, 10},
+		{`100.25`, 100.25},
+		{`9e0`, 9.0},
+		{`[0,0.1,0e1]`, []interface{}{0, 0.1, 0.0}},
+		{`{"a":0}`, map[string]JSON{"a": 0}},
+	}
+
+	for _, tt := range valid {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NewParser(tt.input).Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`00`, 1, "leading zero followed by digit '0'"},
+		{`01`, 1, "leading zero followed by digit '1'"},
+		{`01.2`, 1, "leading zero followed by digit '1'"},
+		{`0123`, 1, "leading zero followed by digit '1'"},
+		{`-00`, 2, "leading zero followed by digit '0'"},
+		{`-01`, 2, "leading zero followed by digit '1'"},
+		{`00.5`, 1, "leading zero followed by digit '0'"},
+		{`00e1`, 1, "leading zero followed by digit '0'"},
+		{`1.`, 2, "unexpected end of input, expected digit after '.'"},
+		{`0.`, 2, "unexpected end of input, expected digit after '.'"},
+		{`[1.]`, 3, "expected digit after '.' but found ']'"},
+		{`1.e5`, 2, "expected digit after '.' but found 'e'"},
+		{`0.e1`, 2, "expected digit after '.' but found 'e'"},
+		{`1e`, 2, "unexpected end of input, expected digit in exponent"},
+		{`1e+`, 3, "unexpected end of input, expected digit in exponent"},
+		{`0e-`, 3, "unexpected end of input, expected digit in exponent"},
+		{`[1e]`, 3, "expected digit in exponent but found ']'"},
+		{`1e+-2`, 3, "expected digit in exponent but found '-'"},
+		{`-`, 1, "expected digit after '-'"},
+		{`-.5`, 1, "expected digit after '-'"},
+		{`0x1`, 1, "expected digit, got 'x'"},
+		{`0-1`, 1, "unexpected '-' in number"},
+		{`1.2.3`, 3, "expected digit, got '.'"},
+		{`1e2e3`, 3, "expected digit, got 'e'"},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+			if perr.msg != tt.msg {
+				t.Errorf("got message %q, want %q", perr.msg, tt.msg)
+			}
+			if err := Validate([]byte(tt.input)); err == nil {
+				t.Error("Validate: expected error")
+			}
+		})
+	}
+}
+
+func TestParseNumberOutOfRange(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   int
+		msg   string
+	}{
+		{`1e999`, 0, "number 1e999 out of range"},
+		{`-1e400`, 0, "number -1e400 out of range"},
+		{`[1e400]`, 1, "number 1e400 out of range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			perr := expectParseError(t, tt.input, tt.pos)
+			if perr.msg != tt.msg {
+				t.Errorf("got message %q, want %q", perr.msg, tt.msg)
+			}
+
+			// The literal is well-formed, only too large to decode.
+			if err := Validate([]byte(tt.input)); err != nil {
+				t.Errorf("Validate: unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseAllowHexNumbers(t *testing.T) {
+	for _, input := range []string{`0xFF`, `[0x10]`, `0X1`} {
+		if _, err := NewParser(input).Parse(); err == nil {
+			t.Errorf("Parse(%s): expected error by default", input)
+		}
+	}
+
+	p := NewParser(`[0xFF, 0x10, 0XaB, -0x1, 0x0, 0x7FFFFFFFFFFFFFFF, -0x8000000000000000, 10]`)
+	p.AllowHexNumbers = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []interface{}{255, 16, 171, -1, 0, intOrInt64(math.MaxInt64), intOrInt64(math.MinInt64), 10}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	p = NewParser(`0x1F`)
+	p.AllowHexNumbers = true
+	p.NumberMode = NumberUseNumber
+	if got, err := p.Parse(); err != nil || got != Number("31") {
+		t.Errorf("NumberUseNumber: got %#v, %v; want Number 31", got, err)
+	}
+
+	invalid := []struct {
+		input string
+		pos   int
+	}{
+		{`0x`, 2},
+		{`[0x]`, 3},
+		{`0xG1`, 2},
+		{`0x1G`, 3},
+		{`0x1.5`, 3},
+		{`0x10000000000000000`, 0},
+		{`0x8000000000000000`, 0},
+		{`00x1`, 1},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.input, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.AllowHexNumbers = true
+
+			_, err := p.Parse()
+			perr, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			if perr.pos != tt.pos {
+				t.Errorf("got error %q at %d, want position %d", perr.msg, perr.pos, tt.pos)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+// Pair is a single member of an OrderedMap.
+type Pair struct {
+	Key   string
+	Value JSON
+}
+
+// OrderedMap is a JSON object that remembers the order in which its keys
+// were added. Parser returns objects as *OrderedMap when PreserveOrder is set.
+type OrderedMap struct {
+	pairs []Pair
+	index map[string]int
+}
+
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{index: make(map[string]int)}
+}
+
+// Set adds or replaces the value for key. A replaced key keeps its original
+// position.
+func (m *OrderedMap) Set(key string, value JSON) {
+	if i, ok := m.index[key]; ok {
+		m.pairs[i].Value = value
+		return
+	}
+	m.index[key] = len(m.pairs)
+	m.pairs = append(m.pairs, Pair{Key: key, Value: value})
+}
+
+// Get returns the value for key and whether it was present.
+func (m *OrderedMap) Get(key string) (JSON, bool) {
+	i, ok := m.index[key]
+	if !ok {
+		return nil, false
+	}
+	return m.pairs[i].Value, true
+}
+
+// Keys returns the keys in insertion order.
+func (m *OrderedMap) Keys() []string {
+	keys := make([]string, len(m.pairs))
+	for i, pair := range m.pairs {
+		keys[i] = pair.Key
+	}
+	return keys
+}
+
+// Pairs returns the members in insertion order. The returned slice must not
+// be modified.
+func (m *OrderedMap) Pairs() []Pair {
+	return m.pairs
+}
+
+func (m *OrderedMap) Len() int {
+	return len(m.pairs)
+}
+
+// Map returns the members as an unordered map.
+func (m *OrderedMap) Map() map[string]JSON {
+	obj := make(map[string]JSON, len(m.pairs))
+	for _, pair := range m.pairs {
+		obj[pair.Key] = pair.Value
+	}
+	return obj
+}
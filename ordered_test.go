@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPreserveOrder(t *testing.T) {
+	p := NewParser(`{"zeta": 1, "alpha": {"y": true, "x": false}, "mid": [{"b": 1, "a": 2}]}`)
+	p.PreserveOrder = true
+
+	got, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	obj, ok := got.(*OrderedMap)
+	if !ok {
+		t.Fatalf("expected *OrderedMap, got %T", got)
+	}
+
+	if want := []string{"zeta", "alpha", "mid"}; !reflect.DeepEqual(obj.Keys(), want) {
+		t.Errorf("got keys %v, want %v", obj.Keys(), want)
+	}
+
+	alpha, _ := obj.Get("alpha")
+	if want := []string{"y", "x"}; !reflect.DeepEqual(alpha.(*OrderedMap).Keys(), want) {
+		t.Errorf("got nested keys %v, want %v", alpha.(*OrderedMap).Keys(), want)
+	}
+
+	if _, ok := obj.Get("missing"); ok {
+		t.Error("expected missing key to be absent")
+	}
+
+	out, err := Marshal(got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := `{"zeta":1,"alpha":{"y":true,"x":false},"mid":[{"b":1,"a":2}]}`; string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+}
+
+func TestOrderedMapSet(t *testing.T) {
+	m := NewOrderedMap()
+	m.Set("b", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+
+	want := []Pair{{Key: "b", Value: 3}, {Key: "a", Value: 2}}
+	if !reflect.DeepEqual(m.Pairs(), want) {
+		t.Errorf("got %v, want %v", m.Pairs(), want)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type PathError struct {
+	msg  string
+	path string
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("Path error at %q: %s", e.path, e.msg)
+}
+
+// Get returns the value at path inside v. The path is a dotted list of
+// object keys with bracketed array indices, e.g. "address.city" or
+// "friends[0]". An empty path returns v itself.
+func Get(v JSON, path string) (JSON, error) {
+	cur := v
+	i := 0
+
+	for i < len(path) {
+		switch path[i] {
+		case '.':
+			if i == 0 || i+1 >= len(path) || path[i+1] == '.' || path[i+1] == '[' {
+				return nil, &PathError{msg: "empty key", path: path[:i+1]}
+			}
+			i++
+
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, &PathError{msg: "unterminated index", path: path}
+			}
+			end += i
+
+			idx, err := strconv.Atoi(path[i+1 : end])
+			if err != nil {
+				return nil, &PathError{msg: fmt.Sprintf("invalid index %q", path[i+1:end]), path: path[:end+1]}
+			}
+
+			arr, ok := AsArray(cur)
+			if !ok {
+				return nil, &PathError{msg: fmt.Sprintf("cannot index %s", kindOf(cur)), path: path[:i]}
+			}
+
+			if idx < 0 || idx >= len(arr) {
+				return nil, &PathError{msg: fmt.Sprintf("index %d out of range for array of length %d", idx, len(arr)), path: path[:end+1]}
+			}
+
+			cur = arr[idx]
+			i = end + 1
+
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path)
+			} else {
+				end += i
+			}
+			key := path[i:end]
+
+			var val JSON
+			var found bool
+			switch obj := cur.(type) {
+			case map[string]JSON:
+				val, found = obj[key]
+			case *OrderedMap:
+				val, found = obj.Get(key)
+			default:
+				return nil, &PathError{msg: fmt.Sprintf("cannot look up key %q in %s", key, kindOf(cur)), path: path[:i]}
+			}
+
+			if !found {
+				return nil, &PathError{msg: fmt.Sprintf("key %q not found", key), path: path[:end]}
+			}
+
+			cur = val
+			i = end
+		}
+	}
+
+	return cur, nil
+}
+
+// Resolve returns the value inside v that the RFC 6901 JSON Pointer refers
+// to, e.g. "/address/city" or "/friends/0". Within a reference token ~1
+// stands for / and ~0 for ~. The empty pointer refers to v itself.
+func Resolve(v JSON, pointer string) (JSON, error) {
+	if pointer == "" {
+		return v, nil
+	}
+	if pointer[0] != '/' {
+		return nil, &PathError{msg: "pointer must be empty or start with '/'", path: pointer}
+	}
+
+	cur := v
+	start := 0
+	for start < len(pointer) {
+		end := strings.IndexByte(pointer[start+1:], '/')
+		if end < 0 {
+			end = len(pointer)
+		} else {
+			end += start + 1
+		}
+
+		token, err := unescapePointerToken(pointer[start+1 : end])
+		if err != nil {
+			return nil, &PathError{msg: err.Error(), path: pointer[:end]}
+		}
+
+		switch c := unwrapRaw(cur).(type) {
+		case map[string]JSON:
+			val, found := c[token]
+			if !found {
+				return nil, &PathError{msg: fmt.Sprintf("key %q not found", token), path: pointer[:end]}
+			}
+			cur = val
+
+		case *OrderedMap:
+			val, found := c.Get(token)
+			if !found {
+				return nil, &PathError{msg: fmt.Sprintf("key %q not found", token), path: pointer[:end]}
+			}
+			cur = val
+
+		case []interface{}:
+			if token == "-" {
+				return nil, &PathError{msg: fmt.Sprintf("index \"-\" is past the end of array of length %d", len(c)), path: pointer[:end]}
+			}
+			idx, ok := pointerIndex(token)
+			if !ok {
+				return nil, &PathError{msg: fmt.Sprintf("invalid index %q", token), path: pointer[:end]}
+			}
+			if idx >= len(c) {
+				return nil, &PathError{msg: fmt.Sprintf("index %d out of range for array of length %d", idx, len(c)), path: pointer[:end]}
+			}
+			cur = c[idx]
+
+		default:
+			return nil, &PathError{msg: fmt.Sprintf("cannot look up %q in %s", token, kindOf(cur)), path: pointer[:start]}
+		}
+
+		start = end
+	}
+
+	return cur, nil
+}
+
+// unescapePointerToken decodes the ~0 and ~1 escapes of a JSON Pointer
+// reference token.
+func unescapePointerToken(token string) (string, error) {
+	if strings.IndexByte(token, '~') < 0 {
+		return token, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(token); i++ {
+		if token[i] != '~' {
+			b.WriteByte(token[i])
+			continue
+		}
+
+		if i+1 >= len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+			return "", fmt.Errorf("invalid escape in %q: '~' must be followed by 0 or 1", token)
+		}
+		if token[i+1] == '0' {
+			b.WriteByte('~')
+		} else {
+			b.WriteByte('/')
+		}
+		i++
+	}
+	return b.String(), nil
+}
+
+// pointerIndex parses a JSON Pointer array index, which is 0 or a decimal
+// number without leading zeros.
+func pointerIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for i := 0; i < len(token); i++ {
+		if !isDigit(token[i]) {
+			return 0, false
+		}
+	}
+
+	idx, err := strconv.Atoi(token)
+	return idx, err == nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const pathDocument = `{
+	"name": "John Doe",
+	"friends": ["Jane", "James", "Jake"],
+	"address": {
+		"city": "New York",
+		"state": "NY",
+		"geo": [[40.7, -74.0]]
+	}
+}`
+
+func TestGet(t *testing.T) {
+	v, err := Unmarshal([]byte(pathDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want JSON
+	}{
+		{"name", "John Doe"},
+		{"address.city", "New York"},
+		{"friends[0]", "Jane"},
+		{"friends[2]", "Jake"},
+		{"address.geo[0][1]", -74.0},
+		{"friends", []interface{}{"Jane", "James", "Jake"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := Get(v, tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := Get(v, ""); err != nil || !reflect.DeepEqual(got, v) {
+		t.Errorf("Get with empty path = %v, %v, want the whole document", got, err)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	v, err := Unmarshal([]byte(pathDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []string{
+		"missing",
+		"address.zip",
+		"friends[3]",
+		"friends[-1]",
+		"friends[x]",
+		"friends[0",
+		"name.first",
+		"address[0]",
+		"address..city",
+		"address.",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			if _, err := Get(v, path); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestGetOrdered(t *testing.T) {
+	p := NewParser(pathDocument)
+	p.PreserveOrder = true
+
+	v, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, err := Get(v, "address.state"); err != nil || got != "NY" {
+		t.Errorf("got %v, %v, want NY", got, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	v, err := Unmarshal([]byte(pathDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		pointer string
+		want    JSON
+	}{
+		{"/name", "John Doe"},
+		{"/address/city", "New York"},
+		{"/friends/0", "Jane"},
+		{"/friends/2", "Jake"},
+		{"/address/geo/0/1", -74.0},
+		{"/friends", []interface{}{"Jane", "James", "Jake"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := Resolve(v, tt.pointer)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	got, err := Resolve(v, "")
+	if err != nil || !reflect.DeepEqual(got, v) {
+		t.Errorf("root pointer: got %#v, %v; want the whole document", got, err)
+	}
+}
+
+func TestResolveEscaping(t *testing.T) {
+	// The examples of RFC 6901 section 5.
+	v, err := Unmarshal([]byte(`{
+		"foo": ["bar", "baz"],
+		"": 0,
+		"a/b": 1,
+		"c%d": 2,
+		"e^f": 3,
+		"g|h": 4,
+		"i\\j": 5,
+		"k\"l": 6,
+		" ": 7,
+		"m~n": 8,
+		"~1": 9
+	}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		pointer string
+		want    JSON
+	}{
+		{"/foo/0", "bar"},
+		{"/", 0},
+		{"/a~1b", 1},
+		{"/c%d", 2},
+		{"/e^f", 3},
+		{"/g|h", 4},
+		{"/i\\j", 5},
+		{`/k"l`, 6},
+		{"/ ", 7},
+		{"/m~0n", 8},
+		{"/~01", 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := Resolve(v, tt.pointer)
+			if err != nil || got != tt.want {
+				t.Errorf("got %#v, %v; want %#v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	v, err := Unmarshal([]byte(pathDocument))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		pointer string
+		path    string
+	}{
+		{"name", "name"},
+		{"/missing", "/missing"},
+		{"/address/zip", "/address/zip"},
+		{"/friends/3", "/friends/3"},
+		{"/friends/-", "/friends/-"},
+		{"/friends/-1", "/friends/-1"},
+		{"/friends/01", "/friends/01"},
+		{"/friends/x", "/friends/x"},
+		{"/friends/", "/friends/"},
+		{"/name/first", "/name"},
+		{"/address/~2", "/address/~2"},
+		{"/address/city~", "/address/city~"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			_, err := Resolve(v, tt.pointer)
+			perr, ok := err.(*PathError)
+			if !ok {
+				t.Fatalf("got %v, want a *PathError", err)
+			}
+			if perr.path != tt.path {
+				t.Errorf("got path %q, want %q", perr.path, tt.path)
+			}
+		})
+	}
+}
+
+func TestResolveOrdered(t *testing.T) {
+	p := NewParser(pathDocument)
+	p.PreserveOrder = true
+
+	v, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, err := Resolve(v, "/address/state"); err != nil || got != "NY" {
+		t.Errorf("got %v, %v, want NY", got, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import "reflect"
+
+// RawMessage is the undecoded source text of a JSON value. As a decode
+// target it captures the exact input bytes of the value so they can be
+// decoded later or passed through unchanged, and Marshal checks that it is
+// well-formed and writes it out verbatim, apart from the string escapes
+// selected by MarshalOptions. A nil RawMessage encodes as null.
+type RawMessage []byte
+
+var rawMessageType = reflect.TypeOf(RawMessage(nil))
+
+// RawValue is a string, number, true, false or null parsed with
+// Parser.PreserveScalars: Value is what it decodes to and Raw its exact
+// source text. Marshal writes Raw back unchanged, so values that are not
+// modified keep their original formatting, such as 1.50 or "caf\u00e9".
+// AsString, AsNumber and AsBool look through a RawValue to its Value.
+type RawValue struct {
+	Value JSON
+	Raw   string
+}
+
+// unwrapRaw returns the decoded value of a RawValue, or v itself.
+func unwrapRaw(v JSON) JSON {
+	if r, ok := v.(RawValue); ok {
+		return r.Value
+	}
+	return v
+}
+
+// JSONUnmarshaler is implemented by types that decode themselves. When a
+// decode destination implements it, UnmarshalJSON is given the source text of
+// the value, or "null" for JSON null.
+type JSONUnmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+var unmarshalerType = reflect.TypeOf((*JSONUnmarshaler)(nil)).Elem()
+
+// needsSource reports whether values of type t can hold a RawMessage, a
+// JSONUnmarshaler or a 64-bit unsigned integer, meaning decoding into t
+// needs the source spans of the input. Integers above MaxInt64 only keep
+// their exact value in the source text.
+func needsSource(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == rawMessageType || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint64:
+		return true
+	}
+
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return needsSource(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if needsSource(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testEnvelope struct {
+	Type    string     `json:"type"`
+	Payload RawMessage `json:"payload"`
+}
+
+func TestRawMessage(t *testing.T) {
+	input := `{"type": "user", "payload": {"name": "Jo",  "friends": ["a", "b"]}}`
+
+	var env testEnvelope
+	if err := UnmarshalInto([]byte(input), &env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := `{"name": "Jo",  "friends": ["a", "b"]}`; string(env.Payload) != want {
+		t.Errorf("got payload %s, want %s", env.Payload, want)
+	}
+
+	var user testUser
+	if err := UnmarshalInto(env.Payload, &user); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+
+	if user.Name != "Jo" || len(user.Friends) != 2 {
+		t.Errorf("got %+v", user)
+	}
+}
+
+func TestRawMessageWithoutSpans(t *testing.T) {
+	v, err := NewParser(`{"type": "n", "payload": [1,  2]}`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var env testEnvelope
+	if err := Populate(v, &env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := `[1,2]`; string(env.Payload) != want {
+		t.Errorf("got payload %s, want %s", env.Payload, want)
+	}
+}
+
+func TestMarshalRawMessage(t *testing.T) {
+	got, err := Marshal(map[string]JSON{"a": RawMessage(`{"x": 1}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := `{"a":{"x": 1}}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMarshalInvalidRawMessage(t *testing.T) {
+	for _, raw := range []string{`{bad`, ``, `[1,]`, `1 2`} {
+		_, err := Marshal([]interface{}{RawMessage(raw)})
+		if _, ok := err.(*MarshalError); !ok {
+			t.Errorf("Marshal(RawMessage(%q)): got %v, want *MarshalError", raw, err)
+		}
+	}
+
+	got, err := Marshal(map[string]JSON{"a": RawMessage(nil)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"a":null}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// unixTime decodes a JSON number of seconds since the Unix epoch.
+type unixTime struct {
+	time.Time
+}
+
+func (u *unixTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	secs, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return errors.New("timestamp must be an integer")
+	}
+	u.Time = time.Unix(secs, 0).UTC()
+	return nil
+}
+
+type testEvent struct {
+	Name    string     `json:"name"`
+	At      unixTime   `json:"at"`
+	Ends    *unixTime  `json:"ends"`
+	Created time.Time  `json:"created"`
+	History []unixTime `json:"history"`
+}
+
+func TestJSONUnmarshaler(t *testing.T) {
+	input := `{"name": "launch", "at": 1700000000, "ends": 1700003600, "created": "2023-11-14T22:13:20Z", "history": [0, 60]}`
+
+	var got testEvent
+	if err := UnmarshalInto([]byte(input), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	at := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	if !got.At.Equal(at) {
+		t.Errorf("got At %v, want %v", got.At, at)
+	}
+	if got.Ends == nil || !got.Ends.Equal(at.Add(time.Hour)) {
+		t.Errorf("got Ends %v, want %v", got.Ends, at.Add(time.Hour))
+	}
+	if !got.Created.Equal(at) {
+		t.Errorf("got Created %v, want %v", got.Created, at)
+	}
+	if len(got.History) != 2 || got.History[1].Unix() != 60 {
+		t.Errorf("got History %v", got.History)
+	}
+
+	// Without source spans the value is re-encoded for UnmarshalJSON.
+	v, err := NewParser(`{"at": 1700000000, "ends": null}`).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got = testEvent{}
+	if err := Populate(v, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.At.Equal(at) || got.Ends != nil {
+		t.Errorf("got %+v", got)
+	}
+
+	err = UnmarshalInto([]byte(`{"at": "soon"}`), &got)
+	derr, ok := err.(*DecodeError)
+	if !ok {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+	if derr.field != "testEvent.At" || derr.msg != "timestamp must be an integer" {
+		t.Errorf("got %q at %q", derr.msg, derr.field)
+	}
+}
+
+func TestPreserveScalars(t *testing.T) {
+	input := `{"name":"caf\u00e9","version":1,"price":1.50,"big":1e3,"path":"\/etc","ok":true,"none":null,"list":[0.10,  -0.0]}`
+
+	p := NewParser(input)
+	p.PreserveOrder = true
+	p.PreserveScalars = true
+
+	v, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc := v.(*OrderedMap)
+
+	name, _ := doc.Get("name")
+	if s, ok := AsString(name); !ok || s != "caf\u00e9" {
+		t.Errorf("AsString(name) = %q, %v", s, ok)
+	}
+	if raw, ok := name.(RawValue); !ok || raw.Raw != `"caf\u00e9"` {
+		t.Errorf("got %#v, want RawValue with its source text", name)
+	}
+	price, _ := doc.Get("price")
+	if f, ok := AsNumber(price); !ok || f != 1.5 {
+		t.Errorf("AsNumber(price) = %v, %v", f, ok)
+	}
+
+	doc.Set("version", 2)
+
+	got, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"name":"caf\u00e9","version":2,"price":1.50,"big":1e3,"path":"\/etc","ok":true,"none":null,"list":[0.10,-0.0]}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	plain, err := NewParser(input).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	doc.Set("version", 1)
+	if d := Diff(doc, plain); d != "" {
+		t.Errorf("preserved tree differs from plain parse: %s", d)
+	}
+
+	var dst struct {
+		Name  string    `json:"name"`
+		Price float64   `json:"price"`
+		List  []float64 `json:"list"`
+	}
+	if err := Populate(doc, &dst); err != nil || dst.Name != "caf\u00e9" || dst.Price != 1.5 || len(dst.List) != 2 {
+		t.Errorf("Populate: got %+v, %v", dst, err)
+	}
+
+	canonical, err := MarshalCanonical(doc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"big":1000,"list":[0.1,0],"name":"caf` + "\u00e9" + `","none":null,"ok":true,"path":"/etc","price":1.5,"version":1}`; string(canonical) != want {
+		t.Errorf("canonical: got %s, want %s", canonical, want)
+	}
+}
+
+func TestPreserveScalarsWithSpans(t *testing.T) {
+	p := NewParser(`{"a":1.50,"b":["x",true]}`)
+	p.PreserveScalars = true
+
+	root, err := p.ParseWithSpans()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a := root.Members["a"]
+	if raw, ok := a.Value.(RawValue); !ok || raw.Raw != "1.50" || raw.Value != 1.5 {
+		t.Errorf("got %#v, want RawValue 1.50", a.Value)
+	}
+	if a.Span != (Span{Start: 5, End: 9}) {
+		t.Errorf("span of a = %+v, want 5-9", a.Span)
+	}
+
+	obj := root.Value.(map[string]JSON)
+	if raw, ok := obj["a"].(RawValue); !ok || raw.Raw != "1.50" {
+		t.Errorf("tree holds %#v, want RawValue 1.50", obj["a"])
+	}
+	if raw, ok := root.Members["b"].Elements[1].Value.(RawValue); !ok || raw.Raw != "true" {
+		t.Errorf("got %#v, want RawValue true", root.Members["b"].Elements[1].Value)
+	}
+
+	got, err := Marshal(root.Value)
+	if err != nil || string(got) != `{"a":1.50,"b":["x",true]}` {
+		t.Errorf("got %s, %v", got, err)
+	}
+}
+
+func TestPreserveScalarsLenientSyntax(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   func(p *Parser)
+		input string
+		want  string
+	}{
+		{"AllowSingleQuotes", func(p *Parser) { p.AllowSingleQuotes = true }, `['a', "bA"]`, `["a","bA"]`},
+		{"AllowHexNumbers", func(p *Parser) { p.AllowHexNumbers = true }, `[0x10, 1.50]`, `[16,1.50]`},
+		{"AllowInfNaN", func(p *Parser) { p.AllowInfNaN = true }, `[NaN, 1.50]`, ""},
+		{"AllowComments", func(p *Parser) { p.AllowComments = true }, `[1.50 /* c */, "x"//d` + "\n]", `[1.50,"x"]`},
+		{"AllowTrailingCommas", func(p *Parser) { p.AllowTrailingCommas = true }, `[1.50, true,]`, `[1.50,true]`},
+		{"AllowExtraWhitespace", func(p *Parser) { p.AllowExtraWhitespace = true }, "[1.50,\u00a0null]", `[1.50,null]`},
+		{"AllowUnquotedKeys", func(p *Parser) { p.AllowUnquotedKeys = true }, `{a: 1.50}`, `{"a":1.50}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(tt.input)
+			p.PreserveScalars = true
+			tt.set(p)
+
+			v, err := p.Parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := Marshal(v)
+			if tt.want == "" {
+				if _, ok := err.(*MarshalError); !ok {
+					t.Fatalf("got %s, %v, want *MarshalError", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if !Valid(got) {
+				t.Errorf("output %s is not valid JSON", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+// ParseAll parses the input like Parse but does not stop at the first error.
+// Each error inside an object or array is recorded, the parser skips ahead
+// to the next ',' or closing bracket at the same nesting level and carries
+// on. It returns the best-effort value along with every error found.
+func (p *Parser) ParseAll() (JSON, []error) {
+	p.collectErrors = true
+	p.errs = nil
+	defer func() { p.collectErrors = false }()
+
+	value, err := p.Parse()
+	if err != nil && !p.recorded(err) {
+		p.errs = append(p.errs, err)
+	}
+
+	return value, p.errs
+}
+
+// recoverError records err while collecting errors for ParseAll and
+// resynchronises the parser at the next ',' or closing bracket. It reports
+// false when err must be returned to the caller instead.
+func (p *Parser) recoverError(err error) bool {
+	if !p.collectErrors || p.recorded(err) {
+		return false
+	}
+
+	if _, ok := err.(*ParseError); !ok {
+		return false
+	}
+
+	p.errs = append(p.errs, p.locate(err))
+	return p.resync()
+}
+
+// recorded reports whether err is the last error ParseAll recorded, so it is
+// not reported again as it unwinds through the enclosing containers.
+func (p *Parser) recorded(err error) bool {
+	return len(p.errs) > 0 && p.errs[len(p.errs)-1] == err
+}
+
+// resync skips ahead to the next ',' or closing bracket outside any nested
+// object, array or string. It reports false if the input ends first.
+func (p *Parser) resync() bool {
+	depth := 0
+
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case '"':
+			p.pos++
+			for p.pos < len(p.input) && p.input[p.pos] != '"' {
+				if p.input[p.pos] == '\\' {
+					p.pos++
+				}
+				p.pos++
+			}
+		case BeginObject, BeginArray:
+			depth++
+		case EndObject, EndArray:
+			if depth == 0 {
+				return true
+			}
+			depth--
+		case ValueSeparator:
+			if depth == 0 {
+				return true
+			}
+		}
+		p.pos++
+	}
+
+	return false
+}
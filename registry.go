@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]reflect.Type{}
+)
+
+// RegisterType makes the type of proto the decode target for discriminator.
+// An interface field whose json tag has the option discriminator=key, such
+// as
+//
+//	Data interface{} `json:"data,discriminator=type"`
+//
+// is decoded into a new value of the type registered for the string under
+// key in the same object, and that value is stored in the field. If proto is
+// a pointer the field receives a pointer. Registering a different type for a
+// discriminator already in use panics.
+func RegisterType(discriminator string, proto interface{}) {
+	t := reflect.TypeOf(proto)
+	if t == nil {
+		panic("json-parser: RegisterType of nil")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if prev, ok := registry[discriminator]; ok && prev != t {
+		panic(fmt.Sprintf("json-parser: RegisterType %q already registered for %s", discriminator, prev))
+	}
+	registry[discriminator] = t
+}
+
+// registeredType returns the type registered for discriminator.
+func registeredType(discriminator string) (reflect.Type, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	t, ok := registry[discriminator]
+	return t, ok
+}
+
+// populateDiscriminated decodes v into the type registered for the
+// discriminator found under key in obj and stores it in the interface rv.
+// Without a discriminator v is stored as it is.
+func (d *decodeState) populateDiscriminated(obj map[string]JSON, key string, v JSON, node *SpanNode, rv reflect.Value, path string) error {
+	found, ok := lookupKey(obj, key, d.opts.CaseSensitiveKeys)
+	raw := obj[found]
+	if !ok || raw == nil || raw == Null {
+		return d.populate(v, node, rv, path)
+	}
+
+	name, ok := AsString(raw)
+	if !ok {
+		return &DecodeError{msg: fmt.Sprintf("discriminator %q must be a string, got %s", key, kindOf(raw)), field: path}
+	}
+
+	t, ok := registeredType(name)
+	if !ok {
+		return &DecodeError{msg: fmt.Sprintf("no type registered for %s %q", key, name), field: path}
+	}
+	if !t.AssignableTo(rv.Type()) {
+		return &DecodeError{msg: fmt.Sprintf("registered type %s for %q does not implement %s", t, name, rv.Type()), field: path}
+	}
+
+	target := reflect.New(t).Elem()
+	if err := d.populate(v, node, target, path); err != nil {
+		return err
+	}
+	rv.Set(target)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testShape interface {
+	Area() float64
+}
+
+type testCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c testCircle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type testRect struct {
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+func (r *testRect) Area() float64 { return r.W * r.H }
+
+type testMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data,discriminator=type"`
+}
+
+type testDrawing struct {
+	Kind  string    `json:"kind"`
+	Shape testShape `json:"shape,discriminator=kind"`
+}
+
+func init() {
+	RegisterType("test.circle", testCircle{})
+	RegisterType("test.rect", &testRect{})
+}
+
+func TestRegisterType(t *testing.T) {
+	var msgs []testMessage
+	input := `[
+		{"type": "test.circle", "data": {"radius": 2}},
+		{"data": {"w": 3, "h": 4}, "type": "test.rect"},
+		{"data": [1]}
+	]`
+	if err := UnmarshalInto([]byte(input), &msgs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := msgs[0].Data, (testCircle{Radius: 2}); got != want {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if got, want := msgs[1].Data, (&testRect{W: 3, H: 4}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if got, want := msgs[2].Data, []interface{}{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("without a discriminator: got %#v, want %#v", got, want)
+	}
+
+	var d testDrawing
+	if err := UnmarshalInto([]byte(`{"kind": "test.rect", "shape": {"w": 2, "h": 5}}`), &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Shape == nil || d.Shape.Area() != 10 {
+		t.Errorf("got shape %#v, want a 2x5 rectangle", d.Shape)
+	}
+}
+
+func TestRegisterTypeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		msg   string
+	}{
+		{`{"type": "test.unknown", "data": {}}`, `no type registered for type "test.unknown"`},
+		{`{"type": 7, "data": {}}`, `discriminator "type" must be a string, got number`},
+		{`{"type": "test.circle", "data": {"radius": "big"}}`, "cannot decode string into float64"},
+	}
+
+	for _, tt := range tests {
+		// Only the discriminated field, so the discriminator itself is not
+		// decoded into a string field first.
+		var m struct {
+			Data interface{} `json:"data,discriminator=type"`
+		}
+		err := UnmarshalInto([]byte(tt.input), &m)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("UnmarshalInto(%s): got %v, want error containing %q", tt.input, err, tt.msg)
+		}
+	}
+
+	RegisterType("test.number", 0)
+	var d testDrawing
+	err := UnmarshalInto([]byte(`{"kind": "test.number", "shape": 1}`), &d)
+	if err == nil || !strings.Contains(err.Error(), "does not implement") {
+		t.Errorf("got %v, want error for a type not implementing testShape", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic registering a second type for test.circle")
+		}
+	}()
+	RegisterType("test.circle", testRect{})
+}
@@ -0,0 +1,105 @@
+package main
+
+// Span is a range of byte offsets in the input, start-inclusive and
+// end-exclusive.
+type Span struct {
+	Start int
+	End   int
+}
+
+// SpanNode records where a parsed value appeared in the input. Members is
+// set for objects and Elements for arrays, mirroring the decoded value.
+type SpanNode struct {
+	Span  Span
+	Value JSON
+
+	Members  map[string]*SpanNode
+	Elements []*SpanNode
+}
+
+// Find returns the innermost node whose span contains offset, or nil if
+// offset lies outside n.
+func (n *SpanNode) Find(offset int) *SpanNode {
+	if offset < n.Span.Start || offset >= n.Span.End {
+		return nil
+	}
+
+	for _, child := range n.Members {
+		if found := child.Find(offset); found != nil {
+			return found
+		}
+	}
+
+	for _, child := range n.Elements {
+		if found := child.Find(offset); found != nil {
+			return found
+		}
+	}
+
+	return n
+}
+
+// ParseWithSpans parses the input like Parse and returns the decoded value
+// wrapped in a tree of SpanNodes recording the source span of every value.
+func (p *Parser) ParseWithSpans() (*SpanNode, error) {
+	root := &SpanNode{}
+	p.spanParent = root
+	defer func() { p.spanParent = nil }()
+
+	if _, err := p.Parse(); err != nil {
+		return nil, err
+	}
+
+	if len(root.Elements) == 0 {
+		return nil, nil
+	}
+
+	return root.Elements[0], nil
+}
+
+// parseValueWithSpan parses the value at the current position and attaches
+// its span to the current parent node.
+func (p *Parser) parseValueWithSpan() (JSON, error) {
+	parent, key := p.spanParent, p.spanKey
+
+	node := &SpanNode{Span: Span{Start: p.pos}}
+	if p.input[p.pos] == BeginObject {
+		node.Members = make(map[string]*SpanNode)
+	}
+
+	p.spanParent = node
+	value, err := p.parsePreserved()
+	p.spanParent = parent
+	if err != nil {
+		return nil, err
+	}
+
+	node.Span.End = p.pos
+	node.Value = value
+
+	if parent.Members != nil {
+		parent.Members[key] = node
+	} else {
+		parent.Elements = append(parent.Elements, node)
+	}
+
+	return value, nil
+}
+
+// omitSpan removes the node of a value the Reviver omitted from the current
+// parent. For an object member, prev is the node of an earlier value under
+// the same key, which the decoded object still holds.
+func (p *Parser) omitSpan(key string, prev *SpanNode) {
+	parent := p.spanParent
+	switch {
+	case parent == nil:
+	case parent.Members != nil:
+		if prev != nil {
+			parent.Members[key] = prev
+		} else {
+			delete(parent.Members, key)
+		}
+	case len(parent.Elements) > 0:
+		parent.Elements = parent.Elements[:len(parent.Elements)-1]
+	}
+}
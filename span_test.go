@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestParseWithSpans(t *testing.T) {
+	input := `{"name": "Jo", "tags": [1, true], "geo": {"lat": -1.5}}`
+
+	root, err := NewParser(input).ParseWithSpans()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		node *SpanNode
+		want Span
+		raw  string
+	}{
+		{"root", root, Span{0, 55}, input},
+		{"name", root.Members["name"], Span{9, 13}, `"Jo"`},
+		{"tags", root.Members["tags"], Span{23, 32}, `[1, true]`},
+		{"tags[0]", root.Members["tags"].Elements[0], Span{24, 25}, `1`},
+		{"tags[1]", root.Members["tags"].Elements[1], Span{27, 31}, `true`},
+		{"geo", root.Members["geo"], Span{41, 54}, `{"lat": -1.5}`},
+		{"geo.lat", root.Members["geo"].Members["lat"], Span{49, 53}, `-1.5`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.node.Span != tt.want {
+				t.Errorf("got span %v, want %v", tt.node.Span, tt.want)
+			}
+
+			if raw := input[tt.node.Span.Start:tt.node.Span.End]; raw != tt.raw {
+				t.Errorf("span covers %q, want %q", raw, tt.raw)
+			}
+		})
+	}
+
+	if got := root.Members["geo"].Members["lat"].Value; got != -1.5 {
+		t.Errorf("got value %v, want -1.5", got)
+	}
+}
+
+func TestSpanNodeFind(t *testing.T) {
+	input := `[10, {"a": "xyz"}]`
+
+	root, err := NewParser(input).ParseWithSpans()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if node := root.Find(13); node == nil || node.Value != "xyz" {
+		t.Errorf("Find(13) = %+v, want the \"xyz\" node", node)
+	}
+
+	if node := root.Find(3); node != root {
+		t.Errorf("Find(3) = %+v, want the root array", node)
+	}
+
+	if node := root.Find(len(input)); node != nil {
+		t.Errorf("Find past the end = %+v, want nil", node)
+	}
+}
+
+func TestParseWithSpansReviverOmit(t *testing.T) {
+	input := `{"a": [1, 2, 3], "b": 2, "c": 3}`
+
+	p := NewParser(input)
+	p.Reviver = func(key string, value JSON) JSON {
+		if value == 2 {
+			return Omit
+		}
+		return value
+	}
+	root, err := p.ParseWithSpans()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := root.Members["b"]; ok {
+		t.Error("omitted member \"b\" still has a span")
+	}
+	if node := root.Members["c"]; node == nil || input[node.Span.Start:node.Span.End] != "3" {
+		t.Errorf("got %+v for \"c\", want the span of 3", node)
+	}
+
+	elements := root.Members["a"].Elements
+	if len(elements) != 2 {
+		t.Fatalf("got %d element spans, want 2", len(elements))
+	}
+	for i, want := range []string{"1", "3"} {
+		if raw := input[elements[i].Span.Start:elements[i].Span.End]; raw != want {
+			t.Errorf("element %d covers %q, want %q", i, raw, want)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MarshalStruct returns the compact JSON encoding of an arbitrary Go value,
+// such as a struct, map, slice or scalar. Struct fields are named by their
+// json tag like in UnmarshalInto, a tag of "-" skips the field, and the
+// omitempty option leaves out fields holding a zero value. Struct fields
+// are written in declaration order and nil pointers, maps and slices
+// become null. The fields of embedded structs are promoted into the outer
+// object following the rules of encoding/json. A value that contains
+// itself, such as a linked list whose last node points back to the first,
+// is an error.
+func MarshalStruct(v interface{}) ([]byte, error) {
+	e := &reflectEncoder{visiting: make(map[visitKey]bool)}
+	tree, err := e.toJSON(reflect.ValueOf(v), "$")
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(tree)
+}
+
+// reflectEncoder carries the state of one MarshalStruct call.
+type reflectEncoder struct {
+	// visiting holds the pointers, maps and slices being converted, to
+	// catch a value that refers back to one of its parents.
+	visiting map[visitKey]bool
+}
+
+// visitKey identifies a pointer, map or slice by its address, type and, for
+// slices, length, so a slice of a prefix of an array is told apart from it.
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter marks rv as being converted, failing if it already is.
+func (e *reflectEncoder) enter(rv reflect.Value, path string) (visitKey, error) {
+	key := visitKey{ptr: rv.Pointer(), typ: rv.Type()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+	if e.visiting[key] {
+		return key, &MarshalError{msg: fmt.Sprintf("cycle through %s at %s", rv.Type(), path)}
+	}
+	e.visiting[key] = true
+	return key, nil
+}
+
+// toJSON converts rv into the tree of values Marshal understands.
+func (e *reflectEncoder) toJSON(rv reflect.Value, path string) (JSON, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	if rv.CanInterface() {
+		switch v := rv.Interface().(type) {
+		case RawMessage, Number, NullValue, *OrderedMap:
+			return v, nil
+		case JSONMarshaler:
+			if rv.Kind() == reflect.Pointer && rv.IsNil() {
+				return nil, nil
+			}
+			return v, nil
+		}
+	}
+
+	// Let an addressable value use MarshalJSON defined on its pointer.
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() && rv.CanInterface() {
+		if m, ok := rv.Addr().Interface().(JSONMarshaler); ok {
+			return m, nil
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		key, err := e.enter(rv, path)
+		if err != nil {
+			return nil, err
+		}
+		defer delete(e.visiting, key)
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return e.toJSON(rv.Elem(), path)
+
+	case reflect.Struct:
+		return e.structToJSON(rv, path)
+
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, &MarshalError{msg: fmt.Sprintf("unsupported map key type %s at %s", rv.Type().Key(), path)}
+		}
+
+		obj := make(map[string]JSON, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			value, err := e.toJSON(iter.Value(), path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			obj[key] = value
+		}
+		return obj, nil
+
+	case reflect.Slice, reflect.Array:
+		arr := make([]interface{}, rv.Len())
+		for i := range arr {
+			value, err := e.toJSON(rv.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = value
+		}
+		return arr, nil
+
+	case reflect.String:
+		return rv.String(), nil
+
+	case reflect.Bool:
+		return rv.Bool(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Number(strconv.FormatUint(rv.Uint(), 10)), nil
+
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &MarshalError{msg: fmt.Sprintf("unsupported float value %v at %s", f, path)}
+		}
+		// Format float32 at its own precision so 0.1 stays 0.1.
+		return Number(strconv.FormatFloat(f, 'g', -1, rv.Type().Bits())), nil
+	}
+
+	return nil, &MarshalError{msg: fmt.Sprintf("unsupported type %s at %s", rv.Type(), path)}
+}
+
+func (e *reflectEncoder) structToJSON(rv reflect.Value, path string) (JSON, error) {
+	obj := NewOrderedMap()
+
+	for _, sf := range structFields(rv.Type()) {
+		field, ok := fieldByIndex(rv, sf.index)
+		if !ok {
+			continue
+		}
+		if omitEmpty(sf.field) && isEmptyValue(field) {
+			continue
+		}
+
+		value, err := e.toJSON(field, path+"."+sf.field.Name)
+		if err != nil {
+			return nil, err
+		}
+		obj.Set(sf.name, value)
+	}
+
+	return obj, nil
+}
+
+// structField is a field of a struct type that has a JSON key, possibly
+// promoted from an embedded struct.
+type structField struct {
+	name  string
+	index []int
+	field reflect.StructField
+
+	// tagged is set when the key comes from a json tag.
+	tagged bool
+}
+
+// structFields returns the fields of the struct type t that have a JSON key,
+// in declaration order. Like encoding/json, it promotes the fields of an
+// embedded struct, or pointer to struct, that has no json tag name. Of
+// several fields with the same key the least deeply embedded one wins, and
+// if there is more than one at that depth the only tagged one; otherwise
+// none of them is used.
+func structFields(t reflect.Type) []structField {
+	var all []structField
+	collectFields(t, nil, map[reflect.Type]bool{}, &all)
+
+	depth := make(map[string]int)
+	count := make(map[string]int)
+	tagged := make(map[string]int)
+	for _, sf := range all {
+		d, ok := depth[sf.name]
+		if ok && len(sf.index) > d {
+			continue
+		}
+		if !ok || len(sf.index) < d {
+			depth[sf.name] = len(sf.index)
+			count[sf.name], tagged[sf.name] = 0, 0
+		}
+		count[sf.name]++
+		if sf.tagged {
+			tagged[sf.name]++
+		}
+	}
+
+	fields := all[:0]
+	for _, sf := range all {
+		if len(sf.index) != depth[sf.name] {
+			continue
+		}
+		if count[sf.name] == 1 || (tagged[sf.name] == 1 && sf.tagged) {
+			fields = append(fields, sf)
+		}
+	}
+	return fields
+}
+
+// collectFields appends the fields of t to fields, descending into embedded
+// structs. index is the path of field indexes leading to t, and visiting
+// holds the embedded struct types being collected, so a type embedding a
+// pointer to itself does not recurse forever.
+func collectFields(t reflect.Type, index []int, visiting map[reflect.Type]bool, fields *[]structField) {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		idx := append(index[:len(index):len(index)], i)
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if !visiting[ft] {
+				collectFields(ft, idx, visiting, fields)
+			}
+			continue
+		}
+
+		if !f.IsExported() && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+			continue
+		}
+		*fields = append(*fields, structField{name: fieldName(f), index: idx, field: f, tagged: name != ""})
+	}
+}
+
+// fieldByIndex returns the field of rv at index, reporting false when it
+// lies behind a nil embedded pointer.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Pointer {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// omitEmpty reports whether the json tag of f has the omitempty option.
+func omitEmpty(f reflect.StructField) bool {
+	_, ok := tagOption(f, "omitempty")
+	return ok
+}
+
+// tagOption looks for the option name in the json tag of f, returning the
+// value after '=' for options written as name=value.
+func tagOption(f reflect.StructField, name string) (string, bool) {
+	_, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if key, value, _ := strings.Cut(opt, "="); key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// isEmptyValue reports whether rv is the zero value omitempty leaves out:
+// false, 0, a nil pointer or interface, or an empty string, slice or map.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	}
+	return false
+}
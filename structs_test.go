@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	ID    int      `json:"id"`
+	Tags  []string `json:"tags,omitempty"`
+	Price float32  `json:"price"`
+}
+
+type testOrder struct {
+	Customer string      `json:"customer"`
+	Note     string      `json:"note,omitempty"`
+	Count    int         `json:"count,omitempty"`
+	Paid     bool        `json:"paid"`
+	Shipping *testItem   `json:"shipping"`
+	Items    []testItem  `json:"items"`
+	Extra    interface{} `json:"extra,omitempty"`
+	Secret   string      `json:"-"`
+	internal int
+	Total    float64
+}
+
+func TestMarshalStruct(t *testing.T) {
+	order := testOrder{
+		Customer: "Jo",
+		Items: []testItem{
+			{ID: 1, Tags: []string{"a"}, Price: 0.1},
+			{ID: 2},
+		},
+		Secret:   "x",
+		internal: 3,
+		Total:    2.5,
+	}
+
+	got, err := MarshalStruct(order)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"customer":"Jo","paid":false,"shipping":null,"items":[{"id":1,"tags":["a"],"price":0.1},{"id":2,"price":0}],"Total":2.5}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMarshalStructValues(t *testing.T) {
+	var nilMap map[string]int
+
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"pointer", &testItem{ID: 7}, `{"id":7,"price":0}`},
+		{"nil pointer", (*testItem)(nil), `null`},
+		{"map", map[string]int{"b": 2, "a": 1}, `{"a":1,"b":2}`},
+		{"nil map", nilMap, `null`},
+		{"uint", uint64(18446744073709551615), `18446744073709551615`},
+		{"array", [2]bool{true, false}, `[true,false]`},
+		{"parsed tree", map[string]JSON{"n": Number("1.50"), "z": Null}, `{"n":1.50,"z":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalStruct(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MarshalStruct(map[int]string{1: "a"}); err == nil {
+		t.Error("expected error for non-string map keys")
+	}
+}
+
+func TestMarshalStructRoundTrip(t *testing.T) {
+	in := testUser{Name: "Jo", Age: 30, Friends: []string{"a"}, Manager: &testUser{Name: "Al"}}
+
+	data, err := MarshalStruct(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out testUser
+	if err := UnmarshalInto(data, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.Name != in.Name || out.Age != in.Age || out.Manager == nil || out.Manager.Name != "Al" {
+		t.Errorf("got %+v, want %+v", out, in)
+	}
+}
+
+// testDate marshals as a quoted YYYY-MM-DD string through a pointer receiver.
+type testDate struct {
+	Year, Month, Day int
+}
+
+func (d *testDate) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%04d-%02d-%02d"`, d.Year, d.Month, d.Day)), nil
+}
+
+type testInvoice struct {
+	Total testMoney  `json:"total"`
+	Paid  *testMoney `json:"paid"`
+	Due   testDate   `json:"due"`
+}
+
+func TestMarshalStructJSONMarshaler(t *testing.T) {
+	inv := &testInvoice{
+		Total: testMoney{Cents: 999, Currency: "USD"},
+		Due:   testDate{2024, 3, 1},
+	}
+
+	got, err := MarshalStruct(inv)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"total":"9.99 USD","paid":null,"due":"2024-03-01"}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+type testNode struct {
+	Value int       `json:"value"`
+	Next  *testNode `json:"next"`
+}
+
+func TestMarshalStructCycle(t *testing.T) {
+	n := &testNode{Value: 1}
+	n.Next = n
+
+	loop := map[string]interface{}{}
+	loop["self"] = loop
+
+	list := []interface{}{nil}
+	list[0] = list
+
+	for name, v := range map[string]interface{}{"pointer": n, "map": loop, "slice": list} {
+		t.Run(name, func(t *testing.T) {
+			_, err := MarshalStruct(v)
+			if _, ok := err.(*MarshalError); !ok || !strings.Contains(err.Error(), "cycle") {
+				t.Errorf("got %v, want a cycle error", err)
+			}
+		})
+	}
+
+	// The same value reached twice without a cycle is fine.
+	shared := &testNode{Value: 2}
+	got, err := MarshalStruct([]*testNode{shared, {Value: 1, Next: shared}})
+	if want := `[{"value":2,"next":null},{"value":1,"next":{"value":2,"next":null}}]`; err != nil || string(got) != want {
+		t.Errorf("got %s, %v; want %s", got, err, want)
+	}
+}
+
+type testInner struct {
+	X int `json:"x"`
+	Z int `json:"z"`
+}
+
+type testOuter struct {
+	testInner
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+type testNamed struct {
+	Name string `json:"name"`
+}
+
+type testTagged struct {
+	Name string
+}
+
+type testEmbedding struct {
+	*testNamed
+	testTagged `json:"tagged"`
+	Ptr        *testInner
+}
+
+// Label is exported so a nil *Label embedded in a struct can be allocated
+// when decoding.
+type Label struct {
+	Text string `json:"text"`
+}
+
+type testLabeled struct {
+	*Label
+	ID int `json:"id"`
+}
+
+type testConflict struct {
+	testNamed
+	Other testNamedAlias
+}
+
+type testNamedAlias struct {
+	testNamed
+}
+
+type testAmbiguous struct {
+	testTagged
+	testAlsoTagged
+	ID int
+}
+
+type testAlsoTagged struct {
+	Name string
+}
+
+func TestMarshalStructEmbedded(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"promoted", testOuter{testInner{X: 1, Z: 9}, 2, 3}, `{"x":1,"y":2,"z":3}`},
+		{"pointer", testEmbedding{testNamed: &testNamed{Name: "a"}}, `{"name":"a","tagged":{"Name":""},"Ptr":null}`},
+		{"nil pointer", testEmbedding{}, `{"tagged":{"Name":""},"Ptr":null}`},
+		{"nested", testConflict{testNamed{"a"}, testNamedAlias{testNamed{"b"}}}, `{"name":"a","Other":{"name":"b"}}`},
+		{"ambiguous", testAmbiguous{testTagged{"a"}, testAlsoTagged{"b"}, 1}, `{"ID":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalStruct(tt.in)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalIntoEmbedded(t *testing.T) {
+	var outer testOuter
+	if err := UnmarshalInto([]byte(`{"x":5,"y":6,"z":7}`), &outer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (testOuter{testInner{X: 5}, 6, 7}); outer != want {
+		t.Errorf("got %+v, want %+v", outer, want)
+	}
+
+	var labeled testLabeled
+	if err := UnmarshalInto([]byte(`{"text":"a","id":1}`), &labeled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labeled.Label == nil || labeled.Text != "a" || labeled.ID != 1 {
+		t.Errorf("got %+v", labeled)
+	}
+
+	var emb testEmbedding
+	if err := UnmarshalInto([]byte(`{"tagged":{"Name":"b"}}`), &emb); err != nil || emb.testTagged.Name != "b" {
+		t.Errorf("got %+v, %v", emb, err)
+	}
+
+	// Like encoding/json, a nil pointer to an unexported embedded struct
+	// cannot be allocated.
+	err := UnmarshalInto([]byte(`{"name":"a"}`), &emb)
+	if err == nil || !strings.Contains(err.Error(), "unexported struct type main.testNamed") {
+		t.Errorf("got %v, want an error for the unexported embedded pointer", err)
+	}
+	emb.testNamed = &testNamed{}
+	if err := UnmarshalInto([]byte(`{"name":"a"}`), &emb); err != nil || emb.testNamed.Name != "a" {
+		t.Errorf("got %+v, %v", emb, err)
+	}
+
+	var strict testOuter
+	err = DecodeOptions{DisallowUnknownFields: true}.UnmarshalInto([]byte(`{"x":1,"testInner":{}}`), &strict)
+	if err == nil || !strings.Contains(err.Error(), `unknown field "testInner"`) {
+		t.Errorf("got %v, want unknown field testInner", err)
+	}
+
+	in := testOuter{testInner{X: 1}, 2, 3}
+	data, err := MarshalStruct(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out testOuter
+	if err := UnmarshalInto(data, &out); err != nil || !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip gave %+v, %v; want %+v", out, err, in)
+	}
+}
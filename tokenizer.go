@@ -0,0 +1,170 @@
+package main
+
+import "fmt"
+
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenBeginObject
+	TokenEndObject
+	TokenBeginArray
+	TokenEndArray
+	TokenNameSeparator
+	TokenValueSeparator
+	TokenString
+	TokenNumber
+	TokenTrue
+	TokenFalse
+	TokenNull
+)
+
+var tokenNames = map[TokenKind]string{
+	TokenEOF:            "EOF",
+	TokenBeginObject:    "BeginObject",
+	TokenEndObject:      "EndObject",
+	TokenBeginArray:     "BeginArray",
+	TokenEndArray:       "EndArray",
+	TokenNameSeparator:  "NameSeparator",
+	TokenValueSeparator: "ValueSeparator",
+	TokenString:         "String",
+	TokenNumber:         "Number",
+	TokenTrue:           "True",
+	TokenFalse:          "False",
+	TokenNull:           "Null",
+}
+
+func (k TokenKind) String() string {
+	if name, ok := tokenNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token is a single lexical element of a JSON document.
+type Token struct {
+	Kind TokenKind
+	Pos  int
+	Raw  string
+
+	// Value holds the decoded string, number or literal for scalar tokens.
+	Value JSON
+}
+
+// Tokenizer splits a JSON document into tokens using the same scanning
+// rules as Parser. It does not check that tokens appear in a valid order.
+type Tokenizer struct {
+	p       *Parser
+	started bool
+}
+
+// NewTokenizer returns a Tokenizer over input with the default options.
+func NewTokenizer(input string) *Tokenizer {
+	return NewTokenizerFromParser(NewParser(input))
+}
+
+// NewTokenizerFromParser returns a Tokenizer reading the input of p with its
+// options, so AllowComments, AllowSingleQuotes, AllowInfNaN and the other
+// lexical options apply, as do NumberMode and MaxInputBytes. Outside an
+// object a bare AllowUnquotedKeys identifier is returned as a TokenString
+// too, since the Tokenizer does not track context. p must not be used for
+// anything else while the Tokenizer is in use.
+func NewTokenizerFromParser(p *Parser) *Tokenizer {
+	return &Tokenizer{p: p}
+}
+
+// Next returns the next token in the input, or a TokenEOF token once the
+// input is exhausted.
+func (t *Tokenizer) Next() (Token, error) {
+	p := t.p
+	if !t.started {
+		t.started = true
+		if err := p.load(); err != nil {
+			return Token{}, err
+		}
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return Token{}, p.locate(err)
+	}
+
+	start := p.pos
+	if start >= len(p.input) {
+		return Token{Kind: TokenEOF, Pos: start}, nil
+	}
+
+	var kind TokenKind
+	var value JSON
+	var err error
+
+	switch c := p.input[p.pos]; {
+	case p.AllowUnquotedKeys && isBareKeyChar(c, false) && !t.isLiteralWord(bareWord(p.input[p.pos:])):
+		kind = TokenString
+		value = p.parseBareKey()
+	case p.AllowInfNaN && nonFiniteLiteral(p.input[p.pos:]) != "":
+		kind = TokenNumber
+		value, err = p.parseLiteral(nonFiniteLiteral(p.input[p.pos:]))
+	case c == BeginObject:
+		kind = TokenBeginObject
+		p.pos++
+	case c == EndObject:
+		kind = TokenEndObject
+		p.pos++
+	case c == BeginArray:
+		kind = TokenBeginArray
+		p.pos++
+	case c == EndArray:
+		kind = TokenEndArray
+		p.pos++
+	case c == NameSeparator:
+		kind = TokenNameSeparator
+		p.pos++
+	case c == ValueSeparator:
+		kind = TokenValueSeparator
+		p.pos++
+	case p.isQuote(c):
+		kind = TokenString
+		value, err = p.parseString()
+	case c == 't':
+		kind = TokenTrue
+		value, err = p.parseLiteral("true")
+	case c == 'f':
+		kind = TokenFalse
+		value, err = p.parseLiteral("false")
+	case c == 'n':
+		kind = TokenNull
+		value, err = p.parseLiteral("null")
+	case c == '-' || isDigit(c):
+		kind = TokenNumber
+		value, err = p.parseNumber()
+	default:
+		err = &ParseError{msg: fmt.Sprintf("unexpected character %s", quoteChar(p.input, p.pos)), pos: p.pos}
+	}
+
+	if err != nil {
+		return Token{}, p.locate(err)
+	}
+
+	return Token{Kind: kind, Pos: start, Raw: string(p.input[start:p.pos]), Value: value}, nil
+}
+
+// bareWord returns the identifier characters at the start of rest.
+func bareWord(rest []byte) string {
+	n := 0
+	for n < len(rest) && isBareKeyChar(rest[n], n > 0) {
+		n++
+	}
+	return string(rest[:n])
+}
+
+// isLiteralWord reports whether word is a literal rather than an
+// AllowUnquotedKeys identifier.
+func (t *Tokenizer) isLiteralWord(word string) bool {
+	switch word {
+	case "true", "false", "null":
+		return true
+	case "NaN", "Infinity":
+		return t.p.AllowInfNaN
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTokenizer(t *testing.T) {
+	tok := NewTokenizer(`{"a": [1, true, null], "b": "x\ny"}`)
+
+	want := []Token{
+		{Kind: TokenBeginObject, Pos: 0, Raw: `{`},
+		{Kind: TokenString, Pos: 1, Raw: `"a"`, Value: "a"},
+		{Kind: TokenNameSeparator, Pos: 4, Raw: `:`},
+		{Kind: TokenBeginArray, Pos: 6, Raw: `[`},
+		{Kind: TokenNumber, Pos: 7, Raw: `1`, Value: 1},
+		{Kind: TokenValueSeparator, Pos: 8, Raw: `,`},
+		{Kind: TokenTrue, Pos: 10, Raw: `true`, Value: true},
+		{Kind: TokenValueSeparator, Pos: 14, Raw: `,`},
+		{Kind: TokenNull, Pos: 16, Raw: `null`},
+		{Kind: TokenEndArray, Pos: 20, Raw: `]`},
+		{Kind: TokenValueSeparator, Pos: 21, Raw: `,`},
+		{Kind: TokenString, Pos: 23, Raw: `"b"`, Value: "b"},
+		{Kind: TokenNameSeparator, Pos: 26, Raw: `:`},
+		{Kind: TokenString, Pos: 28, Raw: `"x\ny"`, Value: "x\ny"},
+		{Kind: TokenEndObject, Pos: 34, Raw: `}`},
+		{Kind: TokenEOF, Pos: 35},
+	}
+
+	var got []Token
+	for {
+		token, err := tok.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, token)
+		if token.Kind == TokenEOF {
+			break
+		}
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestTokenizerError(t *testing.T) {
+	tok := NewTokenizer(`[@]`)
+
+	if _, err := tok.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := tok.Next(); err == nil {
+		t.Error("expected error for unexpected character")
+	}
+}
+
+func TestTokenizerBOM(t *testing.T) {
+	tok := NewTokenizer("\xEF\xBB\xBF[1]")
+
+	token, err := tok.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token.Kind != TokenBeginArray || token.Pos != 3 {
+		t.Errorf("got %+v, want BeginArray at 3", token)
+	}
+}
+
+func TestTokenizerFromParser(t *testing.T) {
+	p := NewParser("// header\n{name: 'x', n: 0x10, inf: -Infinity, nan: NaN}")
+	p.AllowComments = true
+	p.AllowUnquotedKeys = true
+	p.AllowSingleQuotes = true
+	p.AllowHexNumbers = true
+	p.AllowInfNaN = true
+
+	tok := NewTokenizerFromParser(p)
+
+	var kinds []TokenKind
+	var values []JSON
+	for {
+		token, err := tok.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token.Kind == TokenEOF {
+			break
+		}
+		if token.Value != nil {
+			kinds = append(kinds, token.Kind)
+			values = append(values, token.Value)
+		}
+	}
+
+	wantKinds := []TokenKind{TokenString, TokenString, TokenString, TokenNumber, TokenString, TokenNumber, TokenString, TokenNumber}
+	wantValues := []JSON{"name", "x", "n", 16, "inf", math.Inf(-1), "nan", math.NaN()}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("got kinds %v, want %v", kinds, wantKinds)
+	}
+	if len(values) != len(wantValues) {
+		t.Fatalf("got values %v, want %v", values, wantValues)
+	}
+	for i, v := range values {
+		if f, ok := v.(float64); ok && math.IsNaN(f) {
+			if w, ok := wantValues[i].(float64); !ok || !math.IsNaN(w) {
+				t.Errorf("value %d: got NaN, want %v", i, wantValues[i])
+			}
+			continue
+		}
+		if v != wantValues[i] {
+			t.Errorf("value %d: got %#v, want %#v", i, v, wantValues[i])
+		}
+	}
+
+	if _, err := NewTokenizer("// header\n[1]").Next(); err == nil {
+		t.Error("expected error for a comment without AllowComments")
+	}
+}
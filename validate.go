@@ -0,0 +1,30 @@
+package main
+
+// Valid reports whether data is a well-formed JSON document.
+func Valid(data []byte) bool {
+	return Validate(data) == nil
+}
+
+// Validate checks that data is a well-formed JSON document and returns the
+// first error found. Unlike Parse it does not build the decoded value.
+func Validate(data []byte) error {
+	p := NewParserBytes(data)
+	p.validateOnly = true
+	p.skipBOM()
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return p.locate(err)
+	}
+	if _, err := p.parseValue(); err != nil {
+		return p.locate(err)
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return p.locate(err)
+	}
+	if p.pos < len(p.input) {
+		return p.locate(&ParseError{msg: "unexpected trailing characters", pos: p.pos})
+	}
+
+	return nil
+}
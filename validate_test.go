@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	valid := []string{
+		`{"name": "John Doe", "age": 30, "friends": ["Jane", "James"], "address": {"city": "NY"}}`,
+		`[]`,
+		`{}`,
+		`"esc\"aped é"`,
+		` -1.5e3 `,
+		`[true, false, null]`,
+		"\xEF\xBB\xBF[1]",
+	}
+
+	for _, input := range valid {
+		if !Valid([]byte(input)) {
+			t.Errorf("Valid(%q) = false, want true: %v", input, Validate([]byte(input)))
+		}
+	}
+
+	invalid := []string{
+		``,
+		`   `,
+		`{"a": 1,}`,
+		`[1 2]`,
+		`{"a" 1}`,
+		`"unterminated`,
+		`01`,
+		`tru`,
+		`{} x`,
+		`@`,
+	}
+
+	for _, input := range invalid {
+		if Valid([]byte(input)) {
+			t.Errorf("Valid(%q) = true, want false", input)
+		}
+	}
+}
+
+func TestValidateError(t *testing.T) {
+	err := Validate([]byte("{\n  \"a\": [1,]\n}"))
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+
+	if perr.Line != 2 || perr.Column != 11 {
+		t.Errorf("got line %d, column %d, want line 2, column 11", perr.Line, perr.Column)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+// Visitor receives the parts of a JSON document in document order from
+// Parser.Walk. Returning an error from any method stops the walk with that
+// error.
+type Visitor interface {
+	OnObjectStart() error
+	OnKey(key string) error
+	OnObjectEnd() error
+	OnArrayStart() error
+	OnArrayEnd() error
+
+	// OnValue is called for every string, number, bool and null.
+	OnValue(v JSON) error
+}
+
+// Walk parses the input and reports each part of it to v as it goes,
+// without building the decoded tree.
+func (p *Parser) Walk(v Visitor) error {
+	if err := p.load(); err != nil {
+		return err
+	}
+
+	if err := p.walkValue(v); err != nil {
+		return p.locate(err)
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return p.locate(err)
+	}
+	if p.pos < len(p.input) {
+		return p.locate(&ParseError{msg: "unexpected trailing characters", pos: p.pos})
+	}
+
+	return nil
+}
+
+func (p *Parser) walkValue(v Visitor) error {
+	if err := p.skipWhiteSpace(); err != nil {
+		return err
+	}
+
+	if p.pos >= len(p.input) {
+		return &ParseError{msg: "unexpected end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+	}
+
+	if err := p.countValue(); err != nil {
+		return err
+	}
+
+	switch p.input[p.pos] {
+	case BeginObject:
+		return p.walkObject(v)
+	case BeginArray:
+		return p.walkArray(v)
+	}
+
+	value, err := p.parseValueAt()
+	if err != nil {
+		return err
+	}
+	return v.OnValue(value)
+}
+
+func (p *Parser) walkObject(v Visitor) error {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return err
+	}
+
+	p.pos++
+	if err := v.OnObjectStart(); err != nil {
+		return err
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return err
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == EndObject {
+		p.pos++
+		return v.OnObjectEnd()
+	}
+
+	for {
+		if p.pos >= len(p.input) {
+			return &ParseError{msg: "unexpected end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+		}
+
+		if !p.isKeyStart(p.input[p.pos]) {
+			return p.badKey()
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		if err := v.OnKey(key); err != nil {
+			return err
+		}
+
+		if err := p.skipWhiteSpace(); err != nil {
+			return err
+		}
+
+		if p.pos >= len(p.input) {
+			return &ParseError{msg: "expected ':' after object key, got end of input", pos: p.pos, Kind: KindUnexpectedEOF}
+		}
+
+		if p.input[p.pos] != NameSeparator {
+			return p.expected("':' after object key")
+		}
+		p.pos++
+
+		if err := p.walkValue(v); err != nil {
+			return err
+		}
+
+		done, err := p.parseSeparator(EndObject, "',' or '}'")
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return v.OnObjectEnd()
+		}
+	}
+}
+
+func (p *Parser) walkArray(v Visitor) error {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return err
+	}
+
+	p.pos++
+	if err := v.OnArrayStart(); err != nil {
+		return err
+	}
+
+	if err := p.skipWhiteSpace(); err != nil {
+		return err
+	}
+
+	if p.pos < len(p.input) && p.input[p.pos] == EndArray {
+		p.pos++
+		return v.OnArrayEnd()
+	}
+
+	for i := 0; ; i++ {
+		if p.pos < len(p.input) && p.input[p.pos] == ValueSeparator {
+			return p.emptyElement(i)
+		}
+
+		if err := p.walkValue(v); err != nil {
+			return err
+		}
+
+		done, err := p.parseSeparator(EndArray, "',' or ']'")
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return v.OnArrayEnd()
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type collectingVisitor struct {
+	keys    int
+	strings []string
+	events  []string
+	stopAt  string
+}
+
+func (c *collectingVisitor) record(event string) error {
+	c.events = append(c.events, event)
+	if event == c.stopAt {
+		return errors.New("stop")
+	}
+	return nil
+}
+
+func (c *collectingVisitor) OnObjectStart() error { return c.record("{") }
+func (c *collectingVisitor) OnObjectEnd() error   { return c.record("}") }
+func (c *collectingVisitor) OnArrayStart() error  { return c.record("[") }
+func (c *collectingVisitor) OnArrayEnd() error    { return c.record("]") }
+
+func (c *collectingVisitor) OnKey(key string) error {
+	c.keys++
+	return c.record("key:" + key)
+}
+
+func (c *collectingVisitor) OnValue(v JSON) error {
+	if s, ok := v.(string); ok {
+		c.strings = append(c.strings, s)
+	}
+	return c.record("value")
+}
+
+func TestWalk(t *testing.T) {
+	input := `{"name": "John", "tags": ["a", 1, {"b": "c"}], "empty": {}, "ok": true}`
+
+	var v collectingVisitor
+	if err := NewParser(input).Walk(&v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v.keys != 5 {
+		t.Errorf("got %d keys, want 5", v.keys)
+	}
+
+	if want := []string{"John", "a", "c"}; !reflect.DeepEqual(v.strings, want) {
+		t.Errorf("got strings %q, want %q", v.strings, want)
+	}
+
+	want := []string{
+		"{", "key:name", "value", "key:tags", "[", "value", "value", "{", "key:b", "value", "}", "]",
+		"key:empty", "{", "}", "key:ok", "value", "}",
+	}
+	if !reflect.DeepEqual(v.events, want) {
+		t.Errorf("got events %q, want %q", v.events, want)
+	}
+}
+
+func TestWalkStopsOnVisitorError(t *testing.T) {
+	v := collectingVisitor{stopAt: "key:b"}
+	err := NewParser(`{"a": 1, "b": 2, "c": 3}`).Walk(&v)
+	if err == nil || err.Error() != "stop" {
+		t.Fatalf("got %v, want stop", err)
+	}
+
+	if v.keys != 2 {
+		t.Errorf("got %d keys, want 2", v.keys)
+	}
+}
+
+func TestWalkSyntaxError(t *testing.T) {
+	var v collectingVisitor
+	err := NewParser(`[1, 2`).Walk(&v)
+
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("expected *ParseError, got %v", err)
+	}
+}
+
+// progressVisitor records the parser position at every event.
+type progressVisitor struct {
+	p         *Parser
+	positions []int
+}
+
+func (v *progressVisitor) record() { v.positions = append(v.positions, v.p.Position()) }
+
+func (v *progressVisitor) OnObjectStart() error { v.record(); return nil }
+func (v *progressVisitor) OnObjectEnd() error   { v.record(); return nil }
+func (v *progressVisitor) OnArrayStart() error  { v.record(); return nil }
+func (v *progressVisitor) OnArrayEnd() error    { v.record(); return nil }
+func (v *progressVisitor) OnKey(string) error   { v.record(); return nil }
+func (v *progressVisitor) OnValue(JSON) error   { v.record(); return nil }
+
+func TestWalkPosition(t *testing.T) {
+	input := `{"a": [1, 2, {"b": "c"}], "d": true}`
+	p := NewParser(input)
+	if p.Position() != 0 || p.Progress() != 0 {
+		t.Errorf("before parsing: got position %d, progress %v", p.Position(), p.Progress())
+	}
+
+	v := &progressVisitor{p: p}
+	if err := p.Walk(v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(v.positions) != 13 {
+		t.Fatalf("got %d events, want 13", len(v.positions))
+	}
+	for i := 1; i < len(v.positions); i++ {
+		if v.positions[i] <= v.positions[i-1] {
+			t.Errorf("position went from %d to %d at event %d", v.positions[i-1], v.positions[i], i)
+		}
+	}
+
+	if last := v.positions[len(v.positions)-1]; last != len(input) {
+		t.Errorf("got final position %d, want %d", last, len(input))
+	}
+	if p.Progress() != 1 {
+		t.Errorf("got progress %v after walk, want 1", p.Progress())
+	}
+}